@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/thecheerfuldev/gitcd-go/config"
+)
+
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+const (
+	styleReset     = "\033[0m"
+	styleDim       = "\033[2m"
+	styleBasename  = "\033[1;36m"
+	styleHighlight = "\033[1;33m"
+)
+
+// useColor determines whether listings are colorized. It is set from the
+// --color flag before anything is printed.
+var useColor = false
+
+// colorEnabled decides whether to use colors for the given --color setting.
+// With auto, colors are only used when listings are written to a terminal and
+// NO_COLOR is not set.
+func colorEnabled(setting string, terminal bool) (bool, error) {
+	switch setting {
+	case colorAlways:
+		return true, nil
+	case colorNever:
+		return false, nil
+	case colorAuto, "":
+		if noColor, exists := os.LookupEnv("NO_COLOR"); exists && noColor != "" {
+			return false, nil
+		}
+		return terminal, nil
+	}
+	return false, fmt.Errorf("Invalid color setting %q, must be one of: auto, always, never", setting)
+}
+
+// formatPath renders path for a listing. The project root is dimmed, the
+// basename is colored and the characters at positions, which are rune indexes
+// into path, are highlighted.
+func formatPath(path string, positions []int) string {
+	if !useColor {
+		return path
+	}
+
+	runes := []rune(path)
+	rootLength := 0
+	if root := config.Get().ProjectRootPath; root != "" && strings.HasPrefix(path, strings.TrimSuffix(root, "/")+"/") {
+		rootLength = len([]rune(strings.TrimSuffix(root, "/") + "/"))
+	}
+	basenameStart := len([]rune(path[:strings.LastIndex(path, "/")+1]))
+	highlighted := make(map[int]bool, len(positions))
+	for _, position := range positions {
+		highlighted[position] = true
+	}
+
+	var sb strings.Builder
+	current := ""
+	for i, r := range runes {
+		style := ""
+		switch {
+		case highlighted[i]:
+			style = styleHighlight
+		case i < rootLength:
+			style = styleDim
+		case i >= basenameStart:
+			style = styleBasename
+		}
+		if style != current {
+			if current != "" {
+				sb.WriteString(styleReset)
+			}
+			sb.WriteString(style)
+			current = style
+		}
+		sb.WriteRune(r)
+	}
+	if current != "" {
+		sb.WriteString(styleReset)
+	}
+	return sb.String()
+}
+
+// formatDetail renders a detail column next to a path.
+func formatDetail(detail string) string {
+	if !useColor || strings.TrimSpace(detail) == "" {
+		return detail
+	}
+	return styleDim + detail + styleReset
+}
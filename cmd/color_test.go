@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecheerfuldev/gitcd-go/config"
+)
+
+func TestColorEnabled(t *testing.T) {
+	enabled, err := colorEnabled(colorAlways, false)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	enabled, err = colorEnabled(colorNever, true)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+
+	_, err = colorEnabled("sometimes", true)
+	assert.EqualError(t, err, `Invalid color setting "sometimes", must be one of: auto, always, never`)
+}
+
+func TestColorEnabled_auto(t *testing.T) {
+	enabled, err := colorEnabled(colorAuto, false)
+	assert.NoError(t, err)
+	assert.False(t, enabled, "Expected no colors when not writing to a terminal")
+
+	enabled, err = colorEnabled(colorAuto, true)
+	assert.NoError(t, err)
+	assert.True(t, enabled, "Expected colors when writing to a terminal")
+}
+
+func TestColorEnabled_noColor(t *testing.T) {
+	_ = os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+
+	enabled, _ := colorEnabled(colorAuto, true)
+	assert.False(t, enabled, "Expected NO_COLOR to disable colors")
+
+	enabled, _ = colorEnabled(colorAlways, false)
+	assert.True(t, enabled, "Expected always to override NO_COLOR")
+}
+
+func TestFormatPath(t *testing.T) {
+	config.Set(config.Config{ProjectRootPath: "/work"})
+	useColor = true
+	defer func() { useColor = false }()
+
+	actual := formatPath("/work/team/api", []int{11, 12})
+
+	expected := styleDim + "/work/" + styleReset + "team/" + styleHighlight + "ap" + styleReset +
+		styleBasename + "i" + styleReset
+	assert.Equal(t, expected, actual)
+}
+
+func TestFormatPath_noColor(t *testing.T) {
+	useColor = false
+
+	actual := formatPath("/work/team/api", []int{11, 12})
+
+	assert.Equal(t, "/work/team/api", actual, "Expected plain path without colors")
+}
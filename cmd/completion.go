@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+// completeProjects completes a search term with the names of the projects that
+// match it together with the terms before it, ranked like a search. The full
+// path of every project is shown as its description.
+func completeProjects(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	mode, err := extractMatchMode(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	terms := append([]string{}, args...)
+	if toComplete != "" {
+		terms = append(terms, toComplete)
+	}
+	matches, err := repository.SearchProjects(mode, repository.ParseQuery(terms))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	completions := make([]cobra.Completion, 0, len(matches))
+	for _, match := range limitResults(matches) {
+		completions = append(completions, cobra.CompletionWithDesc(filepath.Base(match.Path), match.Path))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeMatchModes completes the --mode flag.
+func completeMatchModes(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	completions := make([]cobra.Completion, len(repository.MatchModes))
+	for i, mode := range repository.MatchModes {
+		completions[i] = string(mode)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// isCompletionRequest reports whether args ask for completions, which is how
+// the completion scripts of the shells call gitcd.
+func isCompletionRequest(args []string) bool {
+	return len(args) > 0 && (args[0] == cobra.ShellCompRequestCmd || args[0] == cobra.ShellCompNoDescRequestCmd)
+}
+
+// separateCompletionTerms does what separateExcludedTerms does for a
+// completion request, while keeping the word that is being completed last.
+func separateCompletionTerms(args []string) []string {
+	if len(args) < 2 || !searchesProjects(args[1:len(args)-1]) {
+		return args
+	}
+	completing := args[len(args)-1]
+	separated := separateExcludedTerms(args[1 : len(args)-1])
+	result := append([]string{args[0]}, separated...)
+	if isExcludedTerm(completing) && !containsSeparator(separated) {
+		result = append(result, "--")
+	}
+	return append(result, completing)
+}
+
+func containsSeparator(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return true
+		}
+	}
+	return false
+}
+
+// completionRegistration matches the lines of the completion scripts that
+// register the completion function for the root command, per shell.
+var completionRegistration = map[string]*regexp.Regexp{
+	"bash": regexp.MustCompile(`(?m)^(\s*complete .*-F __start_gitcd) gitcd$`),
+	"zsh":  regexp.MustCompile(`(?m)^(compdef _gitcd) gitcd$`),
+	"fish": regexp.MustCompile(`(?m)^(complete .*)-c gitcd( .*)$`),
+}
+
+// completionScript returns the completion script of shell, registered for the
+// given names as well, like the shell function and the name of the binary.
+func completionScript(shell string, names ...string) (string, error) {
+	var sb strings.Builder
+	var err error
+	switch shell {
+	case "bash":
+		err = rootCmd.GenBashCompletionV2(&sb, true)
+	case "zsh":
+		err = rootCmd.GenZshCompletion(&sb)
+	case "fish":
+		err = rootCmd.GenFishCompletion(&sb, true)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	registration := completionRegistration[shell]
+	if shell == "fish" {
+		// A fish completion is registered for one command at a time
+		return registration.ReplaceAllStringFunc(sb.String(), func(line string) string {
+			lines := []string{line}
+			for _, name := range names {
+				lines = append(lines, registration.ReplaceAllString(line, "${1}-c "+name+"${2}"))
+			}
+			return strings.Join(lines, "\n")
+		}), nil
+	}
+	return registration.ReplaceAllString(sb.String(), "${1} gitcd "+strings.Join(names, " ")), nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecheerfuldev/gitcd-go/config"
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+func TestCompleteProjects(t *testing.T) {
+	initCompletionTest(t, "/complete/api", "/complete/api-legacy", "/complete/payments")
+	repository.SaveProject(repository.Project{Path: "/complete/api-legacy", CallCounter: 20})
+
+	completions, directive := completeProjects(rootCmd, nil, "api")
+
+	assert.Equal(t, []cobra.Completion{
+		"api-legacy\t/complete/api-legacy",
+		"api\t/complete/api",
+	}, completions, "Should rank the completions like a search")
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveKeepOrder, directive)
+}
+
+func TestCompleteProjects_previousTerms(t *testing.T) {
+	initCompletionTest(t, "/complete/api", "/complete/api-legacy", "/complete/payments")
+
+	completions, _ := completeProjects(rootCmd, []string{"-legacy"}, "")
+
+	assert.Equal(t, []cobra.Completion{
+		"api\t/complete/api",
+		"payments\t/complete/payments",
+	}, completions)
+}
+
+func TestCompleteMatchModes(t *testing.T) {
+	completions, _ := completeMatchModes(rootCmd, nil, "")
+
+	assert.Equal(t, []cobra.Completion{"fuzzy", "regex", "glob", "substring", "exact"}, completions)
+}
+
+func TestSeparateCompletionTerms(t *testing.T) {
+	assert.Equal(t, []string{"__complete", "api", ""}, separateCompletionTerms([]string{"__complete", "api", ""}))
+	assert.Equal(t, []string{"__complete", "api", "--", "-legacy", "pay"}, separateCompletionTerms([]string{"__complete", "-legacy", "api", "pay"}),
+		"Should keep the word that is completed last")
+	assert.Equal(t, []string{"__complete", "api", "--", "-leg"}, separateCompletionTerms([]string{"__complete", "api", "-leg"}))
+	assert.Equal(t, []string{"__complete", "--mo"}, separateCompletionTerms([]string{"__complete", "--mo"}))
+	assert.Equal(t, []string{"__complete", "track", "-dir"}, separateCompletionTerms([]string{"__complete", "track", "-dir"}),
+		"Should leave the arguments of subcommands alone")
+}
+
+func TestCompletionScript(t *testing.T) {
+	bash, err := completionScript("bash", "gcd", "gitcd-go")
+	require.NoError(t, err)
+	assert.Contains(t, bash, "complete -o default -F __start_gitcd gitcd gcd gitcd-go\n")
+
+	zsh, err := completionScript("zsh", "gcd")
+	require.NoError(t, err)
+	assert.Contains(t, zsh, "compdef _gitcd gitcd gcd\n")
+
+	fish, err := completionScript("fish", "gcd")
+	require.NoError(t, err)
+	assert.Contains(t, fish, "complete -c gitcd -e\ncomplete -c gcd -e\n")
+	assert.Equal(t, strings.Count(fish, "-c gitcd "), strings.Count(fish, "-c gcd "), "Should register every completion for gcd too")
+}
+
+func initCompletionTest(t *testing.T, projects ...string) {
+	initTest(t)
+	config.Set(config.Config{MatchMode: string(repository.ModeFuzzy)})
+	repository.ResetDatabase()
+	t.Cleanup(repository.ResetDatabase)
+	for _, project := range projects {
+		repository.AddProject(project)
+	}
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thecheerfuldev/gitcd-go/config"
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+// gitState is the state of the working tree of a project.
+type gitState struct {
+	branch        string
+	dirty         bool
+	ahead, behind int
+}
+
+// gitStates caches the state of every project that was looked up, nil if git
+// didn't answer in time.
+var gitStates = map[string]*gitState{}
+
+// loadGitStates looks up the git state of all paths that aren't cached yet, in
+// parallel. Projects for which git doesn't answer within the configured
+// timeout are shown without their state, so the list stays fast.
+func loadGitStates(paths []string) {
+	timeout := time.Duration(config.Get().GitTimeout) * time.Millisecond
+	if timeout <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	states := map[string]*gitState{}
+	for _, path := range paths {
+		if _, cached := gitStates[path]; cached {
+			continue
+		}
+		states[path] = nil
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			state, err := readGitState(ctx, path)
+			if err != nil {
+				return
+			}
+			mutex.Lock()
+			states[path] = &state
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for path, state := range states {
+		gitStates[path] = state
+	}
+}
+
+// gitStatusWaitDelay is how long a git that was killed after the timeout gets
+// to close its output, before the listing stops waiting for it.
+const gitStatusWaitDelay = 100 * time.Millisecond
+
+// gitStatusCommand runs git status in path without taking the index lock, so
+// it never competes with the user's own git commands and can't leave a stale
+// lock behind when it's killed.
+func gitStatusCommand(ctx context.Context, path string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", "--no-optional-locks", "-C", path, "status", "--porcelain=v2", "--branch")
+	cmd.WaitDelay = gitStatusWaitDelay
+	return cmd
+}
+
+func readGitState(ctx context.Context, path string) (gitState, error) {
+	output, err := gitStatusCommand(ctx, path).Output()
+	if err != nil {
+		return gitState{}, err
+	}
+	return parseGitStatus(string(output)), nil
+}
+
+// parseGitStatus reads the output of git status --porcelain=v2 --branch.
+func parseGitStatus(output string) gitState {
+	state := gitState{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "# branch.head "):
+			state.branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			fields := strings.Fields(strings.TrimPrefix(line, "# branch.ab "))
+			if len(fields) == 2 {
+				state.ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[0], "+"))
+				state.behind, _ = strconv.Atoi(strings.TrimPrefix(fields[1], "-"))
+			}
+		case strings.HasPrefix(line, "#"):
+		case line != "":
+			state.dirty = true
+		}
+	}
+	return state
+}
+
+// detailColumns returns the columns shown next to the path of a project: its
+// branch, whether it's dirty, how far it's ahead and behind, how often it was
+// visited and when it was last visited. Paths that aren't projects, like the
+// directories in a project, have no details.
+func detailColumns(path string, now time.Time) []string {
+	project := repository.GetProject(path)
+	if project.Path == "" {
+		return nil
+	}
+
+	columns := []string{"", "", ""}
+	if state := gitStates[path]; state != nil {
+		columns[0] = state.branch
+		columns[1] = "clean"
+		if state.dirty {
+			columns[1] = "dirty"
+		}
+		var tracking []string
+		if state.ahead > 0 {
+			tracking = append(tracking, fmt.Sprintf("↑%d", state.ahead))
+		}
+		if state.behind > 0 {
+			tracking = append(tracking, fmt.Sprintf("↓%d", state.behind))
+		}
+		columns[2] = strings.Join(tracking, " ")
+	}
+
+	visits := fmt.Sprintf("%d visits", project.CallCounter)
+	if project.CallCounter == 1 {
+		visits = "1 visit"
+	}
+	return append(columns, visits, relativeTime(project.LastVisit, now))
+}
+
+// formatRows renders matches as rows with aligned columns, fitting in width
+// characters. A width of 0 means the rows can be as wide as they need to be.
+func formatRows(matches []repository.Match, width int) []string {
+	paths := make([]string, len(matches))
+	for i, match := range matches {
+		paths[i] = match.Path
+	}
+	loadGitStates(paths)
+
+	now := time.Now()
+	details := make([][]string, len(matches))
+	var columnWidths []int
+	pathWidth := 0
+	for i, match := range matches {
+		pathWidth = max(pathWidth, len([]rune(match.Path)))
+		details[i] = detailColumns(match.Path, now)
+		for j, column := range details[i] {
+			if j >= len(columnWidths) {
+				columnWidths = append(columnWidths, 0)
+			}
+			columnWidths[j] = max(columnWidths[j], len([]rune(column)))
+		}
+	}
+
+	detailsWidth := 0
+	for _, columnWidth := range columnWidths {
+		if columnWidth > 0 {
+			detailsWidth += columnWidth + 2
+		}
+	}
+	if width > 0 {
+		pathWidth = min(pathWidth, width-detailsWidth)
+	}
+
+	rows := make([]string, len(matches))
+	for i, match := range matches {
+		if len(details[i]) == 0 {
+			path, positions := fitPath(match.Path, match.Positions, width)
+			rows[i] = formatPath(path, positions)
+			continue
+		}
+
+		path, positions := fitPath(match.Path, match.Positions, pathWidth)
+		var sb strings.Builder
+		sb.WriteString(formatPath(path, positions))
+		sb.WriteString(strings.Repeat(" ", max(pathWidth-len([]rune(path)), 0)))
+		for j, column := range details[i] {
+			if columnWidths[j] == 0 {
+				continue
+			}
+			sb.WriteString("  ")
+			sb.WriteString(formatDetail(column + strings.Repeat(" ", columnWidths[j]-len([]rune(column)))))
+		}
+		rows[i] = strings.TrimRight(sb.String(), " ")
+	}
+	return rows
+}
+
+// relativeTime describes how long ago t was, in the largest unit that fits.
+func relativeTime(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	elapsed := now.Sub(t)
+	units := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+		{"week", 7 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+	for _, unit := range units {
+		if count := int(elapsed / unit.duration); count >= 1 {
+			if count == 1 {
+				return "1 " + unit.name + " ago"
+			}
+			return fmt.Sprintf("%d %ss ago", count, unit.name)
+		}
+	}
+	return "just now"
+}
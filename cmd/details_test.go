@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecheerfuldev/gitcd-go/config"
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+func TestParseGitStatus(t *testing.T) {
+	output := `# branch.oid 1234567890abcdef
+# branch.head main
+# branch.upstream origin/main
+# branch.ab +2 -1
+1 .M N... 100644 100644 100644 1234567 1234567 README.md
+`
+	state := parseGitStatus(output)
+
+	assert.Equal(t, gitState{branch: "main", dirty: true, ahead: 2, behind: 1}, state)
+}
+
+func TestParseGitStatus_clean(t *testing.T) {
+	output := `# branch.oid 1234567890abcdef
+# branch.head feature/search
+`
+	state := parseGitStatus(output)
+
+	assert.Equal(t, gitState{branch: "feature/search"}, state)
+}
+
+func TestGitStatusCommand(t *testing.T) {
+	cmd := gitStatusCommand(context.Background(), "/work/api")
+
+	assert.Equal(t, []string{"git", "--no-optional-locks", "-C", "/work/api", "status", "--porcelain=v2", "--branch"}, cmd.Args,
+		"Should not take the index lock")
+	assert.Equal(t, gitStatusWaitDelay, cmd.WaitDelay, "Should not wait forever for a killed git")
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "never", relativeTime(time.Time{}, now))
+	assert.Equal(t, "just now", relativeTime(now.Add(-30*time.Second), now))
+	assert.Equal(t, "1 minute ago", relativeTime(now.Add(-time.Minute), now))
+	assert.Equal(t, "5 hours ago", relativeTime(now.Add(-5*time.Hour), now))
+	assert.Equal(t, "2 weeks ago", relativeTime(now.Add(-15*24*time.Hour), now))
+	assert.Equal(t, "1 year ago", relativeTime(now.Add(-400*24*time.Hour), now))
+}
+
+func TestFormatRows(t *testing.T) {
+	initTest(t)
+	config.Set(config.Config{GitTimeout: 0})
+	useColor = false
+	repository.SaveProject(repository.Project{Path: "/work/api", CallCounter: 1, LastVisit: time.Now().Add(-2 * time.Hour)})
+	repository.SaveProject(repository.Project{Path: "/work/payments", CallCounter: 12})
+
+	rows := formatRows(pathMatches([]string{"/work/api", "/work/payments", "/not/a/project"}), 0)
+
+	assert.Equal(t, []string{
+		"/work/api       1 visit    2 hours ago",
+		"/work/payments  12 visits  never",
+		"/not/a/project",
+	}, rows)
+}
+
+func TestFormatRows_fitsWidth(t *testing.T) {
+	initTest(t)
+	config.Set(config.Config{GitTimeout: 0})
+	useColor = false
+	repository.SaveProject(repository.Project{Path: "/work/team/payments", CallCounter: 12})
+
+	rows := formatRows(pathMatches([]string{"/work/team/payments"}), 30)
+
+	assert.Equal(t, []string{"…am/payments  12 visits  never"}, rows)
+}
+
+func TestFormatRows_gitState(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	initTest(t)
+	config.Set(config.Config{GitTimeout: 5000})
+	useColor = false
+	project := t.TempDir()
+	require.NoError(t, exec.Command("git", "-C", project, "init", "--quiet", "--initial-branch", "trunk").Run())
+	createFiles(t, project, "untracked.txt")
+	repository.SaveProject(repository.Project{Path: project, CallCounter: 3})
+
+	rows := formatRows(pathMatches([]string{project}), 0)
+
+	assert.Equal(t, []string{project + "  trunk  dirty  3 visits  never"}, rows)
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+// queryPlaceholder is replaced with the query in the picker command.
+const queryPlaceholder = "{query}"
+
+// pickerQueryFlags are the flags that set the initial filter of well-known
+// pickers, used when the picker command has no query placeholder.
+var pickerQueryFlags = map[string]string{
+	"fzf": "--query",
+	"sk":  "--query",
+	"gum": "--value",
+}
+
+// runExternalPicker pipes the paths of matches to the picker command, and
+// returns the line it prints. It returns false if the user cancels the picker
+// or nothing matches in it.
+func runExternalPicker(command string, matches []repository.Match, query string) (string, bool, error) {
+	args := pickerArgs(command, query)
+	if len(args) == 0 {
+		return "", false, errors.New("picker command is empty")
+	}
+
+	lines := make([]string, len(matches))
+	for i, match := range matches {
+		lines[i] = match.Path
+	}
+
+	picker := exec.Command(args[0], args[1:]...)
+	picker.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n")
+	picker.Stderr = os.Stderr
+	output, err := picker.Output()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// fzf, sk and gum exit with 1 when nothing matches, and 130 when the
+		// user cancels
+		if code := exitErr.ExitCode(); code == 1 || code == 130 {
+			return "", false, nil
+		}
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("Error running picker %s: %w", args[0], err)
+	}
+
+	choice := strings.TrimRight(string(output), "\r\n")
+	return choice, choice != "", nil
+}
+
+// pickerArgs splits the picker command into arguments, and adds the query as
+// the initial filter.
+func pickerArgs(command, query string) []string {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return args
+	}
+
+	if strings.Contains(command, queryPlaceholder) {
+		for i, arg := range args {
+			args[i] = strings.ReplaceAll(arg, queryPlaceholder, query)
+		}
+		return args
+	}
+
+	if flag, known := pickerQueryFlags[filepath.Base(args[0])]; known && query != "" {
+		args = append(args, flag, query)
+	}
+	return args
+}
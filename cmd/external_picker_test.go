@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecheerfuldev/gitcd-go/config"
+)
+
+func TestPickerArgs_placeholder(t *testing.T) {
+	args := pickerArgs("my-picker --filter={query} --height 40%", "api")
+	assert.Equal(t, []string{"my-picker", "--filter=api", "--height", "40%"}, args)
+}
+
+func TestPickerArgs_knownPicker(t *testing.T) {
+	assert.Equal(t, []string{"fzf", "--height", "40%", "--query", "api"}, pickerArgs("fzf --height 40%", "api"))
+	assert.Equal(t, []string{"/usr/bin/sk", "--query", "api"}, pickerArgs("/usr/bin/sk", "api"))
+	assert.Equal(t, []string{"gum", "filter", "--value", "api"}, pickerArgs("gum filter", "api"))
+}
+
+func TestPickerArgs_withoutQuery(t *testing.T) {
+	assert.Equal(t, []string{"fzf"}, pickerArgs("fzf", ""))
+	assert.Equal(t, []string{"other-picker"}, pickerArgs("other-picker", "api"), "Unknown pickers should not get a query flag")
+}
+
+func TestRunExternalPicker(t *testing.T) {
+	dir := t.TempDir()
+	// The fake picker records its arguments and input, and picks the second line
+	picker := writeScript(t, dir, "picker", `echo "$@" > `+filepath.Join(dir, "args")+`
+cat > `+filepath.Join(dir, "input")+`
+sed -n 2p `+filepath.Join(dir, "input"))
+
+	choice, chosen, err := runExternalPicker(picker+" --query={query}", pathMatches([]string{"/a", "/b", "/c"}), "api")
+
+	require.NoError(t, err)
+	assert.True(t, chosen)
+	assert.Equal(t, "/b", choice)
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	assert.Equal(t, "--query=api\n", string(args))
+	input, _ := os.ReadFile(filepath.Join(dir, "input"))
+	assert.Equal(t, "/a\n/b\n/c\n", string(input))
+}
+
+func TestRunExternalPicker_cancelled(t *testing.T) {
+	for _, code := range []string{"1", "130"} {
+		picker := writeScript(t, t.TempDir(), "picker", "cat > /dev/null\nexit "+code)
+
+		_, chosen, err := runExternalPicker(picker, pathMatches([]string{"/a"}), "")
+
+		assert.NoError(t, err, "Exit code %s should not be an error", code)
+		assert.False(t, chosen, "Exit code %s should cancel", code)
+	}
+}
+
+func TestRunExternalPicker_failed(t *testing.T) {
+	picker := writeScript(t, t.TempDir(), "picker", "cat > /dev/null\nexit 2")
+
+	_, chosen, err := runExternalPicker(picker, pathMatches([]string{"/a"}), "")
+
+	assert.Error(t, err)
+	assert.False(t, chosen)
+}
+
+func TestRunExternalPicker_notFound(t *testing.T) {
+	_, _, err := runExternalPicker("/does/not/exist", pathMatches([]string{"/a"}), "")
+
+	assert.Error(t, err)
+}
+
+func writeScript(t *testing.T, dir, name, body string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755))
+	return path
+}
+
+func TestHandleMultipleMatches_pickerFailed(t *testing.T) {
+	config.Set(config.Config{Picker: filepath.Join(t.TempDir(), "missing-picker")})
+	printed := fakeOutput(t, outputOptions{print: true, ask: true})
+	prompt := fakePrompt(t, "")
+
+	handleMultipleMatches(candidates{matches: pathMatches([]string{"/work/api", "/work/web"})}, destination{})
+
+	assert.Empty(t, printed.String(), "Should keep the error away from the path the shell function changes to")
+	assert.Contains(t, prompt.String(), "Error running picker")
+	assert.Equal(t, exitError, exitCode, "Should not report a failing picker as cancelled")
+}
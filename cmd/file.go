@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// validateFilePattern checks that pattern is a valid file name or glob, so a
+// typo isn't mistaken for a file that doesn't exist.
+func validateFilePattern(pattern string) error {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("Invalid file pattern %s: %w", pattern, err)
+	}
+	return nil
+}
+
+// findFileDirectories returns the directories inside root that contain a file
+// matching pattern, which is either a file name or a glob. Files ignored by git
+// are skipped.
+func findFileDirectories(root, pattern string) []string {
+	found := map[string]bool{}
+	for _, file := range projectFiles(root) {
+		if matched, _ := filepath.Match(pattern, filepath.Base(file)); matched {
+			found[filepath.Join(root, filepath.Dir(file))] = true
+		}
+	}
+
+	directories := make([]string, 0, len(found))
+	for directory := range found {
+		directories = append(directories, directory)
+	}
+	// Directories closest to the root come first
+	sort.Slice(directories, func(i, j int) bool {
+		depthI, depthJ := strings.Count(directories[i], "/"), strings.Count(directories[j], "/")
+		if depthI != depthJ {
+			return depthI < depthJ
+		}
+		return directories[i] < directories[j]
+	})
+	return directories
+}
+
+// projectFiles returns the files in root relative to it: the files git tracks
+// and the untracked files that aren't ignored by .gitignore and the like. When
+// git can't list them, every file outside the ignored directories is returned.
+func projectFiles(root string) []string {
+	output, err := exec.Command("git", "-C", root, "ls-files", "--cached", "--others", "--exclude-standard", "-z").Output()
+	if err != nil {
+		return walkFiles(root)
+	}
+
+	files := make([]string, 0)
+	for _, file := range bytes.Split(output, []byte{0}) {
+		if len(file) > 0 {
+			files = append(files, filepath.FromSlash(string(file)))
+		}
+	}
+	return files
+}
+
+func walkFiles(root string) []string {
+	files := make([]string, 0)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip directories we can't access
+		}
+		if d.IsDir() {
+			if ignoredDirectories[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relative, _ := filepath.Rel(root, path)
+		files = append(files, relative)
+		return nil
+	})
+	return files
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindFileDirectories(t *testing.T) {
+	project := createProjectTree(t, "deploy/docker", "svc/api", "node_modules/pkg", ".github/workflows")
+	createFiles(t, project, "Dockerfile", "deploy/docker/Dockerfile", "svc/api/main.go",
+		"node_modules/pkg/Dockerfile", ".github/workflows/ci.yml")
+
+	directories := findFileDirectories(project, "Dockerfile")
+
+	assert.Equal(t, []string{project, filepath.Join(project, "deploy", "docker")}, directories,
+		"Expected every directory containing the file, outside of the ignored directories")
+	assert.Equal(t, []string{filepath.Join(project, ".github", "workflows")}, findFileDirectories(project, "*.yml"),
+		"Expected hidden directories to be searched")
+}
+
+func TestFindFileDirectories_gitIgnore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	project := createProjectTree(t, "dist", "node_modules/pkg", "out", ".github/workflows")
+	createFiles(t, project, ".gitignore", "dist/Dockerfile", "node_modules/pkg/Dockerfile", "out/Dockerfile",
+		".github/workflows/Dockerfile")
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".gitignore"), []byte("node_modules/\nout/\ndist/\n"), 0644))
+	runGit(t, project, "init", "--quiet")
+	// A tracked file is found, even in an ignored directory
+	runGit(t, project, "add", "--force", "dist/Dockerfile")
+
+	directories := findFileDirectories(project, "Dockerfile")
+
+	assert.Equal(t, []string{filepath.Join(project, "dist"), filepath.Join(project, ".github", "workflows")}, directories,
+		"Expected tracked and untracked files, but not ignored ones")
+}
+
+func TestValidateFilePattern(t *testing.T) {
+	assert.NoError(t, validateFilePattern(""))
+	assert.NoError(t, validateFilePattern("*.sql"))
+	assert.ErrorIs(t, validateFilePattern("[schema.sql"), filepath.ErrBadPattern)
+}
+
+func TestFindFileDirectories_glob(t *testing.T) {
+	project := createProjectTree(t, "db", "svc/api")
+	createFiles(t, project, "db/schema.sql", "db/seed.sql", "svc/api/main.go")
+
+	directories := findFileDirectories(project, "*.sql")
+
+	assert.Equal(t, []string{filepath.Join(project, "db")}, directories, "Expected every directory only once")
+}
+
+func TestFindFileDirectories_noMatch(t *testing.T) {
+	project := createProjectTree(t, "svc/api")
+	createFiles(t, project, "svc/api/main.go")
+
+	directories := findFileDirectories(project, "schema.sql")
+
+	assert.Empty(t, directories)
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func createFiles(t *testing.T, root string, files ...string) {
+	for _, file := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(root, file), []byte{}, 0644))
+	}
+}
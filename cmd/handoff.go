@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// handoffPattern matches the scripts that hand the directory to change to over
+// to the shell, one per invocation.
+const handoffPattern = "change_dir.*.sh"
+
+// staleHandoffAge is the age after which a handoff script can't belong to a
+// running invocation anymore. The wrapper removes the script right after
+// sourcing it, so older ones are left behind by shells that were killed.
+const staleHandoffAge = time.Hour
+
+// writeHandoff creates the handoff script at path. The script is only readable
+// by the user, and creating it fails if it already exists, so gitcd never
+// writes into a file that someone else prepared.
+func writeHandoff(path string, script []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(script); err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
+
+// clearHandoffs removes the handoff script of this invocation, left behind by
+// an earlier run that crashed, and the scripts of other invocations that are
+// too old to be sourced by anyone.
+func clearHandoffs(path string, now time.Time) {
+	_ = os.Remove(path)
+
+	scripts, err := filepath.Glob(filepath.Join(filepath.Dir(path), handoffPattern))
+	if err != nil {
+		return
+	}
+	for _, script := range scripts {
+		info, err := os.Lstat(script)
+		if err == nil && now.Sub(info.ModTime()) > staleHandoffAge {
+			_ = os.Remove(script)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHandoff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "change_dir.42.sh")
+
+	require.NoError(t, writeHandoff(path, []byte("cd /work/api")))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "cd /work/api", string(content))
+}
+
+func TestWriteHandoff_exists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "change_dir.42.sh")
+	require.NoError(t, os.WriteFile(path, []byte("cd /somewhere/else"), 0644))
+
+	err := writeHandoff(path, []byte("cd /work/api"))
+
+	assert.ErrorIs(t, err, os.ErrExist)
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "cd /somewhere/else", string(content), "Should not write into an existing file")
+}
+
+func TestClearHandoffs(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	own := filepath.Join(dir, "change_dir.42.sh")
+	running := filepath.Join(dir, "change_dir.43.sh")
+	stale := filepath.Join(dir, "change_dir.44.sh")
+	database := filepath.Join(dir, "gitcd.db")
+	for _, path := range []string{own, running, stale, database} {
+		require.NoError(t, os.WriteFile(path, []byte{}, 0600))
+	}
+	require.NoError(t, os.Chtimes(stale, now.Add(-2*time.Hour), now.Add(-2*time.Hour)))
+	require.NoError(t, os.Chtimes(database, now.Add(-2*time.Hour), now.Add(-2*time.Hour)))
+
+	clearHandoffs(own, now)
+
+	assert.NoFileExists(t, own, "Should remove the script of an earlier run")
+	assert.FileExists(t, running, "Should keep the script of another invocation")
+	assert.NoFileExists(t, stale, "Should remove scripts that are too old to be used")
+	assert.FileExists(t, database)
+}
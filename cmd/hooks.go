@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thecheerfuldev/gitcd-go/config"
+	"github.com/thecheerfuldev/gitcd-go/hooks"
+)
+
+const revokeFlag = "revoke"
+const yesFlag = "yes"
+
+// hooksCmd prints the hooks of a project as a script, so the shell function of
+// gitcd init can run them in the shell after changing directory.
+var hooksCmd = &cobra.Command{
+	Use:   "hooks <dir>",
+	Short: "Print the commands to run after jumping to the git project that contains dir",
+	Long: `Print the commands to run after jumping to the git project that contains dir,
+one per line, for the shell to run. The hooks file in the gitcd home directory
+can hold global hooks, and hooks per root, per tag or per project:
+
+  nvm use --silent        # before any section, runs after every jump
+  [root ~/work]
+  git fetch --quiet &
+  [tag node]
+  nvm use
+  [project ~/work/api]
+  source .venv/bin/activate
+
+Tags are set in the tags file, one project per line, like ~/work/web;node,web.
+The .gitcd-hooks file inside a project only runs once it's trusted with
+gitcd trust. Hooks run from the root of the project, and the shell changes
+back to dir after them.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectories,
+	Run: func(cmd *cobra.Command, args []string) {
+		target, err := filepath.Abs(args[0])
+		if err != nil {
+			tell("Error reading directory:", err)
+			exitCode = exitError
+			return
+		}
+		project := projectDirectory(target)
+		_, _ = fmt.Fprint(scriptOut, string(hookScript(project, target, projectHooks(project))))
+	},
+}
+
+// trustCmd allows the hooks file inside a project to run. The trust is tied to
+// the content of the file, so changing it needs another trust.
+var trustCmd = &cobra.Command{
+	Use:   "trust [dir]",
+	Short: "Allow the .gitcd-hooks file of the git project that contains dir to run",
+	Long: `Show the .gitcd-hooks file of the git project that contains dir, the current
+directory by default, and ask whether its commands may run after a jump. The
+file has to be trusted again after it changes. Use --yes to trust it without
+asking, and --revoke to stop it from running.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeDirectories,
+	Run: func(cmd *cobra.Command, args []string) {
+		revoke, err := cmd.Flags().GetBool(revokeFlag)
+		if err != nil {
+			tell("Error reading revoke flag:", err)
+			exitCode = exitError
+			return
+		}
+
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		project := projectDirectory(dir)
+
+		if revoke {
+			err = hooks.Revoke(config.Get().TrustFilePath, project)
+			if err != nil {
+				tell("Error revoking trust:", err)
+				exitCode = exitError
+				return
+			}
+			tell("The hooks in", project, "no longer run")
+			return
+		}
+
+		yes, err := cmd.Flags().GetBool(yesFlag)
+		if err != nil {
+			tell("Error reading yes flag:", err)
+			exitCode = exitError
+			return
+		}
+		if !yes {
+			closePrompt := openPrompt()
+			defer closePrompt()
+		}
+
+		trusted, err := trustProject(project, yes)
+		if err != nil {
+			tell(err)
+			exitCode = exitError
+			return
+		}
+		if !trusted {
+			exitCode = exitCancelled
+		}
+	},
+}
+
+// projectDirectory returns the git project that contains dir, or dir itself
+// when it isn't inside a project.
+func projectDirectory(dir string) string {
+	if root, found := findProjectRoot(dir); found {
+		return root
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	return abs
+}
+
+// projectHooks returns the commands to run after jumping to project: the
+// hooks from the hooks file that apply to it, followed by the hooks inside the
+// project if those are trusted. Hooks that can't be read are skipped with a
+// warning, they never stop the jump.
+func projectHooks(project string) []string {
+	cfg := config.Get()
+	globalHooks, err := hooks.ReadFile(cfg.HooksFilePath)
+	if err != nil {
+		tell("Error reading hooks file:", err)
+	}
+	tags, err := hooks.ReadTags(cfg.TagsFilePath)
+	if err != nil {
+		tell("Error reading tags file:", err)
+	}
+	commands := hooks.Commands(globalHooks, project, tags[filepath.Clean(project)])
+
+	content, local, err := hooks.ReadLocal(project)
+	if err != nil {
+		tellf("Error reading %s in %s: %v\n", hooks.LocalFileName, project, err)
+		return commands
+	}
+	if content == nil {
+		return commands
+	}
+	trusted, err := hooks.Trusted(cfg.TrustFilePath, project, content)
+	if err != nil {
+		tell("Error reading trusted hooks:", err)
+	}
+	if !trusted {
+		tellf("Not running the untrusted %s in %s, check it and run: gitcd trust %s\n", hooks.LocalFileName, project, shellQuote(project))
+		return commands
+	}
+	return append(commands, local...)
+}
+
+// hookScript turns commands into lines of a shell script that runs them from
+// the root of project, so relative paths in hooks work after jumping to a
+// subdirectory, and changes back to target after them.
+func hookScript(project, target string, commands []string) []byte {
+	if len(commands) == 0 {
+		return nil
+	}
+	if filepath.Clean(project) == filepath.Clean(target) {
+		return []byte(strings.Join(commands, "\n") + "\n")
+	}
+	return []byte("cd " + shellQuote(project) + "\n" + strings.Join(commands, "\n") + "\ncd " + shellQuote(target) + "\n")
+}
+
+// trustProject shows the hooks file inside project, and trusts its content if
+// the user agrees or yes is set. It returns whether the hooks were trusted.
+func trustProject(project string, yes bool) (bool, error) {
+	content, _, err := hooks.ReadLocal(project)
+	if err != nil {
+		return false, fmt.Errorf("Error reading %s in %s: %w", hooks.LocalFileName, project, err)
+	}
+	if content == nil {
+		return false, fmt.Errorf("No %s found in %s", hooks.LocalFileName, project)
+	}
+
+	tell(strings.TrimRight(string(content), "\n"))
+	if !yes {
+		tellf("Run these commands after every jump to %s? [y/N] ", project)
+		if answer := strings.ToLower(readChoice()); answer != "y" && answer != "yes" {
+			tell("The hooks in", project, "are not trusted")
+			return false, nil
+		}
+	}
+	if err := hooks.Trust(config.Get().TrustFilePath, project, content); err != nil {
+		return false, fmt.Errorf("Error trusting hooks: %w", err)
+	}
+	tell("The hooks above run after every jump to", project, "until the file changes")
+	return true, nil
+}
+
+func init() {
+	trustCmd.Flags().BoolP(revokeFlag, "", false, "Stop the hooks of the project from running")
+	trustCmd.Flags().BoolP(yesFlag, "", false, "Trust the hooks without asking")
+	rootCmd.AddCommand(hooksCmd)
+	rootCmd.AddCommand(trustCmd)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecheerfuldev/gitcd-go/config"
+	"github.com/thecheerfuldev/gitcd-go/hooks"
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+// initHooksTest sets up a gitcd home with the given hooks and tags files.
+func initHooksTest(t *testing.T, hooksFile, tagsFile string) {
+	home := t.TempDir()
+	config.Set(config.Config{
+		GitCdHomePath:    home,
+		DatabaseFilePath: filepath.Join(home, "gitcd.db"),
+		DirChangerPath:   filepath.Join(home, "change_dir.test.sh"),
+		HooksFilePath:    filepath.Join(home, "hooks"),
+		TagsFilePath:     filepath.Join(home, "tags"),
+		TrustFilePath:    filepath.Join(home, "trusted"),
+	})
+	require.NoError(t, os.WriteFile(config.Get().HooksFilePath, []byte(hooksFile), 0644))
+	require.NoError(t, os.WriteFile(config.Get().TagsFilePath, []byte(tagsFile), 0644))
+}
+
+func TestProjectHooks(t *testing.T) {
+	project := createProjectTree(t, ".git")
+	initHooksTest(t, "echo global\n[tag node]\nnvm use\n[project "+project+"]\necho project\n", project+";node\n")
+	fakePrompt(t, "")
+
+	assert.Equal(t, []string{"echo global", "nvm use", "echo project"}, projectHooks(project))
+	assert.Equal(t, []string{"echo global"}, projectHooks(t.TempDir()))
+}
+
+func TestProjectHooks_untrusted(t *testing.T) {
+	project := createProjectTree(t, ".git")
+	initHooksTest(t, "echo global\n", "")
+	require.NoError(t, os.WriteFile(filepath.Join(project, hooks.LocalFileName), []byte("make deps\n"), 0644))
+	prompt := fakePrompt(t, "")
+
+	assert.Equal(t, []string{"echo global"}, projectHooks(project), "Should not run untrusted hooks")
+	assert.Contains(t, prompt.String(), "gitcd trust '"+project+"'")
+
+	trusted, err := trustProject(project, true)
+	require.NoError(t, err)
+	assert.True(t, trusted)
+	assert.Equal(t, []string{"echo global", "make deps"}, projectHooks(project))
+
+	require.NoError(t, os.WriteFile(filepath.Join(project, hooks.LocalFileName), []byte("make deps\nrm -rf ~\n"), 0644))
+	assert.Equal(t, []string{"echo global"}, projectHooks(project), "Should not run hooks that changed since they were trusted")
+}
+
+func TestProjectHooks_invalidFile(t *testing.T) {
+	project := createProjectTree(t, ".git")
+	initHooksTest(t, "[branch main]\necho main\n", "")
+	prompt := fakePrompt(t, "")
+
+	assert.Empty(t, projectHooks(project))
+	assert.Contains(t, prompt.String(), "Error reading hooks file")
+}
+
+func TestTrustProject_asks(t *testing.T) {
+	initHooksTest(t, "", "")
+	project := createProjectTree(t, ".git")
+	require.NoError(t, os.WriteFile(filepath.Join(project, hooks.LocalFileName), []byte("make deps\n"), 0644))
+
+	prompt := fakePrompt(t, "\n")
+	trusted, err := trustProject(project, false)
+	require.NoError(t, err)
+	assert.False(t, trusted, "Should not trust the hooks unless the user agrees")
+	assert.Contains(t, prompt.String(), "make deps\nRun these commands after every jump to "+project+"? [y/N] ")
+	assert.Empty(t, projectHooks(project))
+
+	fakePrompt(t, "y\n")
+	trusted, err = trustProject(project, false)
+	require.NoError(t, err)
+	assert.True(t, trusted)
+	assert.Equal(t, []string{"make deps"}, projectHooks(project))
+}
+
+func TestTrustProject_noHooks(t *testing.T) {
+	initHooksTest(t, "", "")
+	project := createProjectTree(t, ".git")
+
+	_, err := trustProject(project, true)
+	assert.EqualError(t, err, "No .gitcd-hooks found in "+project)
+}
+
+func TestChangeDirectory_hooks(t *testing.T) {
+	project := createProjectTree(t, ".git", "src")
+	initHooksTest(t, "echo global\n", "")
+	_ = repository.Init(config.Get())
+	t.Cleanup(repository.ResetDatabase)
+	repository.AddProject(project)
+	fakePrompt(t, "")
+
+	changeDirectory(project, filepath.Join(project, "src"))
+
+	script, err := os.ReadFile(config.Get().DirChangerPath)
+	require.NoError(t, err)
+	src := filepath.Join(project, "src")
+	assert.Equal(t, "cd '"+src+"'\ncd '"+project+"'\necho global\ncd '"+src+"'\n", string(script),
+		"Should run the hooks from the root of the project")
+}
+
+func TestHookScript(t *testing.T) {
+	assert.Nil(t, hookScript("/work/api", "/work/api/src", nil))
+	assert.Equal(t, "nvm use\nls\n", string(hookScript("/work/api", "/work/api/", []string{"nvm use", "ls"})))
+	assert.Equal(t, "cd '/work/api'\nnvm use\ncd '/work/api/src'\n", string(hookScript("/work/api", "/work/api/src", []string{"nvm use"})))
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+const cmdFlag = "cmd"
+const keyFlag = "key"
+const trackFlag = "track"
+
+// defaultBinary is called by the shell function when the name gitcd was
+// started with can't be used in a shell script as is.
+const defaultBinary = "gitcd"
+
+var validName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+var validBinary = regexp.MustCompile(`^[A-Za-z0-9._+-]+$`)
+
+// initCmd prints a shell function that changes directory to the project gitcd
+// prints, so there's no need for a script that has to be sourced.
+var initCmd = &cobra.Command{
+	Use:       "init <bash|zsh|fish>",
+	Short:     "Print the shell function that changes directory, add it to your shell profile",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Long: `Print the shell function that changes directory to the project you pick.
+Add it to your shell profile, for example:
+
+  eval "$(gitcd init bash)"            # ~/.bashrc
+  eval "$(gitcd init zsh)"             # ~/.zshrc
+  gitcd init fish | source             # ~/.config/fish/config.fish
+
+Use --cmd to choose the name of the function, and --key to bind a key that
+opens the picker, like '\C-g' in bash, '^G' in zsh or \cg in fish. Use
+--track to also count visits to projects you enter without gitcd.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, err := cmd.Flags().GetString(cmdFlag)
+		if err != nil {
+			tell("Error reading cmd flag:", err)
+			exitCode = exitError
+			return
+		}
+		key, err := cmd.Flags().GetString(keyFlag)
+		if err != nil {
+			tell("Error reading key flag:", err)
+			exitCode = exitError
+			return
+		}
+
+		track, err := cmd.Flags().GetBool(trackFlag)
+		if err != nil {
+			tell("Error reading track flag:", err)
+			exitCode = exitError
+			return
+		}
+
+		script, err := shellInit(args[0], shellFunction{Name: name, Binary: binaryName(os.Args[0]), Key: key, Track: track})
+		if err != nil {
+			tell(err)
+			exitCode = exitError
+			return
+		}
+		fmt.Print(script)
+	},
+}
+
+// binaryName returns the name to call gitcd by from the shell function. That's
+// the name it was started with, so it keeps working when the binary is
+// installed under another name, like gitcd-go.
+func binaryName(arg0 string) string {
+	name := filepath.Base(arg0)
+	if !validBinary.MatchString(name) {
+		return defaultBinary
+	}
+	return name
+}
+
+// shellFunction describes the shell function to generate. Name is the name of
+// the function, Binary the command it calls, Key the key that opens the picker
+// and Track whether visits without gitcd are counted.
+type shellFunction struct {
+	Name, Binary, Key, Completion string
+	Track                         bool
+}
+
+var shellTemplates = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(posixTemplate + bashWidgetTemplate + bashTrackTemplate + bashCompletionTemplate)),
+	"zsh":  template.Must(template.New("zsh").Parse(posixTemplate + zshWidgetTemplate + zshTrackTemplate + zshCompletionTemplate)),
+	"fish": template.Must(template.New("fish").Parse(fishTemplate + fishTrackTemplate + fishCompletionTemplate)),
+}
+
+// shellInit renders the shell function f for shell. If f has a key, it's bound
+// to a widget that opens the picker.
+func shellInit(shell string, f shellFunction) (string, error) {
+	tmpl, supported := shellTemplates[shell]
+	if !supported {
+		return "", fmt.Errorf("Unsupported shell %s, use bash, zsh or fish", shell)
+	}
+	if !validName.MatchString(f.Name) {
+		return "", fmt.Errorf("Invalid function name %s", f.Name)
+	}
+	// The key ends up between quotes in the script
+	if strings.ContainsAny(f.Key, `'"`) {
+		return "", fmt.Errorf("Invalid key %s, it can't contain quotes", f.Key)
+	}
+
+	// Complete the function and the binary like gitcd itself
+	names := []string{f.Name}
+	if f.Binary != rootCmd.Name() {
+		names = append(names, f.Binary)
+	}
+	completion, err := completionScript(shell, names...)
+	if err != nil {
+		return "", err
+	}
+	f.Completion = completion
+
+	var sb strings.Builder
+	err = tmpl.Execute(&sb, f)
+	return sb.String(), err
+}
+
+// posixTemplate calls gitcd so that it prints the path of the project instead
+// of changing directory, then changes directory itself and runs the hooks of
+// the project. Anything else gitcd prints, like the help text, is passed on,
+// and completion requests go to gitcd as they are.
+const posixTemplate = `{{.Name}}() {
+  case "$1" in
+    __complete*) command {{.Binary}} "$@"; return ;;
+  esac
+  local dir
+  dir="$(command {{.Binary}} --print --interactive "$@")" || return
+  if [ -d "$dir" ]; then
+    cd -- "$dir"{{if .Track}}
+    # The jump was counted already, don't count it again in the hook
+    __{{.Name}}_dir="$PWD"{{end}}
+    eval "$(command {{.Binary}} hooks "$PWD")"
+  elif [ -n "$dir" ]; then
+    printf '%s\n' "$dir"
+  fi
+}
+`
+
+const bashWidgetTemplate = `{{if .Key}}
+__{{.Name}}_widget() {
+  {{.Name}} </dev/tty
+}
+bind -x '"{{.Key}}": __{{.Name}}_widget'
+{{end}}`
+
+const zshWidgetTemplate = `{{if .Key}}
+__{{.Name}}_widget() {
+  {{.Name}} </dev/tty
+  zle reset-prompt
+}
+zle -N __{{.Name}}_widget
+bindkey '{{.Key}}' __{{.Name}}_widget
+{{end}}`
+
+const fishTemplate = `function {{.Name}}
+    if string match -q -- '__complete*' "$argv[1]"
+        command {{.Binary}} $argv
+        return
+    end
+    set -l dir (command {{.Binary}} --print --interactive $argv)
+    or return
+    if test (count $dir) -eq 1 -a -d "$dir[1]"
+        cd $dir[1]{{if .Track}}
+        # The jump was counted already, don't count it again in the hook
+        set -g __{{.Name}}_dir $PWD{{end}}
+        command {{.Binary}} hooks $PWD | source
+    else if test (count $dir) -gt 0
+        printf '%s\n' $dir
+    end
+end
+{{if .Key}}
+function __{{.Name}}_widget
+    {{.Name}} </dev/tty
+    commandline -f repaint
+end
+bind '{{.Key}}' __{{.Name}}_widget
+{{end}}`
+
+// The track templates count a visit whenever the prompt shows up in another
+// directory than the last time, so moving into a project with a plain cd
+// counts too.
+const posixTrackFunctionTemplate = `
+__{{.Name}}_track() {
+  if [ "$PWD" != "$__{{.Name}}_dir" ]; then
+    command {{.Binary}} track --from "$__{{.Name}}_dir" "$PWD"
+    __{{.Name}}_dir="$PWD"
+  fi
+}
+`
+
+const bashTrackTemplate = `{{if .Track}}` + posixTrackFunctionTemplate + `case ";${PROMPT_COMMAND[*]};" in
+  *";__{{.Name}}_track;"*) ;;
+  *) PROMPT_COMMAND="__{{.Name}}_track${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+{{end}}`
+
+const zshTrackTemplate = `{{if .Track}}` + posixTrackFunctionTemplate + `autoload -Uz add-zsh-hook
+add-zsh-hook precmd __{{.Name}}_track
+{{end}}`
+
+const fishTrackTemplate = `{{if .Track}}
+function __{{.Name}}_track --on-event fish_prompt
+    if test "$PWD" != "$__{{.Name}}_dir"
+        command {{.Binary}} track --from "$__{{.Name}}_dir" "$PWD"
+        set -g __{{.Name}}_dir $PWD
+    end
+end
+{{end}}`
+
+const bashCompletionTemplate = `
+{{.Completion}}`
+
+// zshCompletionTemplate only registers the completion when the completion
+// system is loaded, compdef doesn't exist before compinit runs.
+const zshCompletionTemplate = `
+if (( $+functions[compdef] )); then
+{{.Completion}}
+fi
+`
+
+const fishCompletionTemplate = `
+{{.Completion}}`
+
+func init() {
+	initCmd.Flags().StringP(cmdFlag, "", "gcd", "Name of the shell function")
+	initCmd.Flags().StringP(keyFlag, "", "", "Key to bind to a widget that opens the picker, in the notation of the shell")
+	initCmd.Flags().BoolP(trackFlag, "", false, "Count visits to projects that you enter without gitcd, like with cd")
+	rootCmd.AddCommand(initCmd)
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellInit_bash(t *testing.T) {
+	script, err := shellInit("bash", shellFunction{Name: "gcd", Binary: "gitcd"})
+
+	require.NoError(t, err)
+	assert.Contains(t, script, "gcd() {\n")
+	assert.Contains(t, script, `dir="$(command gitcd --print --interactive "$@")" || return`)
+	assert.NotContains(t, script, "bind -x", "Should not bind a key unless asked")
+	assert.Contains(t, script, "complete -o default -F __start_gitcd gitcd gcd\n", "Should complete the function")
+}
+
+func TestShellInit_bashKey(t *testing.T) {
+	script, err := shellInit("bash", shellFunction{Name: "gcd", Binary: "gitcd", Key: `\C-g`})
+
+	require.NoError(t, err)
+	assert.Contains(t, script, `bind -x '"\C-g": __gcd_widget'`)
+}
+
+func TestShellInit_zshKey(t *testing.T) {
+	script, err := shellInit("zsh", shellFunction{Name: "j", Binary: "gitcd-go", Key: "^G"})
+
+	require.NoError(t, err)
+	assert.Contains(t, script, "j() {\n")
+	assert.Contains(t, script, "command gitcd-go --print --interactive")
+	assert.Contains(t, script, "zle -N __j_widget\nbindkey '^G' __j_widget\n")
+	assert.Contains(t, script, "if (( $+functions[compdef] )); then\n#compdef gitcd\n")
+	assert.Contains(t, script, "compdef _gitcd gitcd j gitcd-go\n")
+}
+
+func TestShellInit_fish(t *testing.T) {
+	script, err := shellInit("fish", shellFunction{Name: "gcd", Binary: "gitcd", Key: `\cg`})
+
+	require.NoError(t, err)
+	assert.Contains(t, script, "function gcd\n")
+	assert.Contains(t, script, "set -l dir (command gitcd --print --interactive $argv)\n    or return\n")
+	assert.Contains(t, script, "        command gitcd hooks $PWD | source\n")
+	assert.Contains(t, script, `bind '\cg' __gcd_widget`)
+}
+
+func TestShellInit_track(t *testing.T) {
+	script, err := shellInit("bash", shellFunction{Name: "gcd", Binary: "gitcd"})
+	require.NoError(t, err)
+	assert.NotContains(t, script, "track", "Should not track unless asked")
+
+	script, err = shellInit("bash", shellFunction{Name: "gcd", Binary: "gitcd", Track: true})
+	require.NoError(t, err)
+	assert.Contains(t, script, `command gitcd track --from "$__gcd_dir" "$PWD"`)
+	assert.Contains(t, script, `PROMPT_COMMAND="__gcd_track${PROMPT_COMMAND:+;$PROMPT_COMMAND}"`)
+
+	script, err = shellInit("zsh", shellFunction{Name: "gcd", Binary: "gitcd", Track: true})
+	require.NoError(t, err)
+	assert.Contains(t, script, "add-zsh-hook precmd __gcd_track\n")
+
+	script, err = shellInit("fish", shellFunction{Name: "gcd", Binary: "gitcd", Track: true})
+	require.NoError(t, err)
+	assert.Contains(t, script, "function __gcd_track --on-event fish_prompt\n")
+	assert.Contains(t, script, "        set -g __gcd_dir $PWD\n")
+}
+
+func TestShellInit_invalid(t *testing.T) {
+	_, err := shellInit("tcsh", shellFunction{Name: "gcd", Binary: "gitcd"})
+	assert.EqualError(t, err, "Unsupported shell tcsh, use bash, zsh or fish")
+
+	_, err = shellInit("bash", shellFunction{Name: "g cd", Binary: "gitcd"})
+	assert.EqualError(t, err, "Invalid function name g cd")
+
+	_, err = shellInit("bash", shellFunction{Name: "gcd", Binary: "gitcd", Key: `"`})
+	assert.Error(t, err)
+}
+
+func TestBinaryName(t *testing.T) {
+	assert.Equal(t, "gitcd-go", binaryName("/usr/local/bin/gitcd-go"))
+	assert.Equal(t, "gitcd", binaryName("/tmp/my gitcd"))
+}
+
+func TestShellInit_runsInBash(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	project := t.TempDir()
+	bin := t.TempDir()
+	writeScript(t, bin, "gitcd", `[ "$1" = hooks ] && echo 'echo "hook in $PWD"' && exit
+case "$3" in
+  jump) echo "`+project+`" ;;
+  help) echo "Usage: gitcd" ;;
+  *) exit 2 ;;
+esac`)
+	script, err := shellInit("bash", shellFunction{Name: "gcd", Binary: "gitcd"})
+	require.NoError(t, err)
+
+	test := script + `
+gcd jump && pwd
+gcd help
+gcd nothing
+echo "status $?"
+`
+	shell := exec.Command("bash", "--noprofile", "--norc", "-c", test)
+	shell.Env = []string{"PATH=" + bin + ":/usr/bin:/bin"}
+	output, err := shell.CombinedOutput()
+
+	require.NoError(t, err, string(output))
+	assert.Equal(t, []string{"hook in " + project, project, "Usage: gitcd", "status 2"}, strings.Split(strings.TrimSpace(string(output)), "\n"))
+}
+
+func TestInitCmd_invalid(t *testing.T) {
+	fakeOutput(t, outputOptions{})
+	prompt := fakePrompt(t, "")
+
+	initCmd.Run(initCmd, []string{"tcsh"})
+
+	assert.Equal(t, exitError, exitCode)
+	assert.Contains(t, prompt.String(), "Unsupported shell tcsh", "Should not print errors for the shell to evaluate")
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes, so scripts can tell why gitcd didn't change directory.
+const (
+	exitOK           = 0
+	exitError        = 1
+	exitNoMatch      = 2
+	exitAmbiguous    = 3
+	exitCancelled    = 4
+	exitInvalidQuery = 5
+)
+
+// exitCode is the code gitcd exits with once the command is done.
+var exitCode = exitOK
+
+// cancel records that nothing was chosen, unless choosing failed with an error
+// that was recorded already.
+func cancel() {
+	if exitCode == exitOK {
+		exitCode = exitCancelled
+	}
+}
+
+// scriptOut receives the paths printed for scripts. Nothing else is written to
+// it, so its output can be used as is.
+var scriptOut io.Writer = os.Stdout
+
+// outputOptions determine what gitcd does with the matches: change directory
+// to one of them, open it in tmux, or print them for a script.
+type outputOptions struct {
+	// print writes the path of the best match instead of changing directory.
+	print bool
+	// first takes the best match instead of asking which one to take.
+	first bool
+	// all writes the paths of all matches.
+	all bool
+	// null ends every printed path with a NUL character instead of a newline.
+	null bool
+	// ask lets gitcd ask which project to take, even when printing.
+	ask bool
+	// tmux opens the project in a tmux session instead of changing directory.
+	tmux bool
+	// split opens the project in a new pane of the current tmux window.
+	split bool
+}
+
+var output outputOptions
+
+// interactive reports whether gitcd may ask the user anything.
+func (o outputOptions) interactive() bool {
+	return o.ask || (!o.print && !o.all)
+}
+
+// printPaths writes paths to scriptOut, each one ending with a newline, or with
+// a NUL character if that was asked for.
+func printPaths(paths ...string) {
+	terminator := "\n"
+	if output.null {
+		terminator = "\x00"
+	}
+	for _, path := range paths {
+		_, _ = fmt.Fprint(scriptOut, path+terminator)
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+func TestHandleMatches_print(t *testing.T) {
+	printed := fakeOutput(t, outputOptions{print: true})
+	fakePrompt(t, "")
+
+	handleMatches(testCandidates("/work/api", "/work/web"), destination{}, true)
+
+	assert.Equal(t, "/work/api\n", printed.String())
+	assert.Equal(t, exitOK, exitCode)
+}
+
+func TestHandleMatches_printAmbiguous(t *testing.T) {
+	printed := fakeOutput(t, outputOptions{print: true})
+	messages := fakePrompt(t, "")
+
+	handleMatches(testCandidates("/work/api", "/work/web"), destination{}, false)
+
+	assert.Empty(t, printed.String(), "Should not print anything when the match is ambiguous")
+	assert.Contains(t, messages.String(), "2 projects match")
+	assert.Equal(t, exitAmbiguous, exitCode)
+}
+
+func TestHandleMatches_printSingle(t *testing.T) {
+	printed := fakeOutput(t, outputOptions{print: true})
+	fakePrompt(t, "")
+
+	handleMatches(testCandidates("/work/api"), destination{}, false)
+
+	assert.Equal(t, "/work/api\n", printed.String())
+	assert.Equal(t, exitOK, exitCode)
+}
+
+func TestHandleMatches_printFirst(t *testing.T) {
+	printed := fakeOutput(t, outputOptions{print: true, first: true, null: true})
+	fakePrompt(t, "")
+
+	handleMatches(testCandidates("/work/api", "/work/web"), destination{}, false)
+
+	assert.Equal(t, "/work/api\x00", printed.String())
+}
+
+func TestHandleMatches_all(t *testing.T) {
+	printed := fakeOutput(t, outputOptions{all: true, null: true})
+	fakePrompt(t, "")
+
+	handleMatches(testCandidates("/work/api", "/work/web"), destination{}, false)
+
+	assert.Equal(t, "/work/api\x00/work/web\x00", printed.String())
+	assert.Equal(t, exitOK, exitCode)
+}
+
+func TestHandleMatches_cancelled(t *testing.T) {
+	printed := fakeOutput(t, outputOptions{})
+	fakePrompt(t, "q\n")
+
+	handleMatches(testCandidates("/work/api", "/work/web"), destination{}, false)
+
+	assert.Empty(t, printed.String())
+	assert.Equal(t, exitCancelled, exitCode)
+}
+
+func TestHandleMatches_printFileAmbiguous(t *testing.T) {
+	printed := fakeOutput(t, outputOptions{print: true})
+	fakePrompt(t, "")
+	project := createProjectTree(t, "api", "web")
+	createFiles(t, project, "api/main.go", "web/main.go")
+
+	handleMatches(testCandidates(project), destination{file: "main.go"}, true)
+
+	assert.Empty(t, printed.String())
+	assert.Equal(t, exitAmbiguous, exitCode)
+}
+
+func TestHandleMatches_printInteractive(t *testing.T) {
+	initTest(t)
+	t.Cleanup(repository.ResetDatabase)
+	printed := fakeOutput(t, outputOptions{print: true, ask: true})
+	fakePrompt(t, "2\n")
+	repository.AddProject("/shell/api")
+	repository.AddProject("/shell/web")
+
+	handleMatches(testCandidates("/shell/api", "/shell/web"), destination{}, false)
+
+	assert.Equal(t, "/shell/web\n", printed.String())
+	assert.Equal(t, 1, repository.GetProject("/shell/web").CallCounter, "Should count the visit")
+}
+
+func TestHandleNoMatches(t *testing.T) {
+	initTest(t)
+	fakeOutput(t, outputOptions{print: true})
+	messages := fakePrompt(t, "")
+	repository.AddProject("/work/payments")
+
+	handleNoMatches(repository.ModeFuzzy, []string{"paymnets"}, nil, destination{})
+
+	assert.Equal(t, "No projects found\n", messages.String(), "Should not offer suggestions to scripts")
+	assert.Equal(t, exitNoMatch, exitCode)
+}
+
+func TestHandleNoMatches_invalidQuery(t *testing.T) {
+	initTest(t)
+	fakeOutput(t, outputOptions{print: true})
+	fakePrompt(t, "")
+
+	_, err := repository.SearchProjects(repository.ModeRegex, repository.ParseQuery([]string{"("}))
+	handleNoMatches(repository.ModeRegex, []string{"("}, err, destination{})
+
+	assert.Equal(t, exitInvalidQuery, exitCode)
+}
+
+func TestOutputOptions_interactive(t *testing.T) {
+	assert.True(t, outputOptions{}.interactive())
+	assert.True(t, outputOptions{first: true}.interactive())
+	assert.False(t, outputOptions{print: true}.interactive())
+	assert.False(t, outputOptions{all: true}.interactive())
+	assert.True(t, outputOptions{print: true, ask: true}.interactive())
+}
+
+// fakeOutput captures the paths printed for scripts, and resets the output
+// options and exit code when the test is done.
+func fakeOutput(t *testing.T, options outputOptions) *strings.Builder {
+	printed := &strings.Builder{}
+	previousOut := scriptOut
+	scriptOut = printed
+	output = options
+	exitCode = exitOK
+	t.Cleanup(func() {
+		scriptOut = previousOut
+		output = outputOptions{}
+		exitCode = exitOK
+	})
+	return printed
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/thecheerfuldev/gitcd-go/config"
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+type keyKind int
+
+const (
+	keyRune keyKind = iota
+	keyEnter
+	keyEscape
+	keyUp
+	keyDown
+	keyBackspace
+	keyClearQuery
+	keyInterrupt
+	keyIgnored
+)
+
+type key struct {
+	kind keyKind
+	r    rune
+}
+
+type pickerAction int
+
+const (
+	pickerContinue pickerAction = iota
+	pickerAccept
+	pickerCancel
+)
+
+// picker is a full-screen list of candidates that can be filtered by typing.
+type picker struct {
+	query    []rune
+	matches  []repository.Match
+	selected int
+	offset   int
+	refine   refineFunc
+	err      error
+	preview  previewFunc
+	previews map[string][]string
+}
+
+// minPreviewWidth is the narrowest terminal the preview is shown on, below it
+// the list needs all the room it can get.
+const minPreviewWidth = 60
+
+func newPicker(c candidates) *picker {
+	return &picker{query: []rune(c.query), matches: c.matches, refine: c.refine, previews: map[string][]string{}}
+}
+
+// runPicker shows the picker on t until the user selects a candidate or
+// cancels. It returns false if the user cancels.
+func runPicker(t *terminal, c candidates) (string, bool) {
+	p := newPicker(c)
+	p.preview = newPreview(config.Get().Preview)
+	// Use the alternate screen, so the picker disappears when it's done
+	_, _ = io.WriteString(t.out, "\033[?1049h")
+	defer io.WriteString(t.out, "\033[?1049l")
+
+	buf := make([]byte, 64)
+	for {
+		width, height := t.size()
+		p.render(t.out, width, height)
+
+		n, err := t.in.Read(buf)
+		if err != nil {
+			return "", false
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			switch p.handleKey(k) {
+			case pickerAccept:
+				return p.matches[p.selected].Path, true
+			case pickerCancel:
+				return "", false
+			}
+		}
+	}
+}
+
+// parseKeys decodes the bytes read from a terminal in raw mode into keys.
+func parseKeys(buf []byte) []key {
+	keys := make([]key, 0, len(buf))
+	for len(buf) > 0 {
+		switch {
+		case buf[0] == '\033' && len(buf) >= 3 && (buf[1] == '[' || buf[1] == 'O'):
+			// An escape sequence ends with a byte in the range @ to ~, after
+			// optional parameters like the ones used for function keys
+			end := 2
+			for end < len(buf)-1 && (buf[end] < 0x40 || buf[end] > 0x7e) {
+				end++
+			}
+			switch buf[end] {
+			case 'A':
+				keys = append(keys, key{kind: keyUp})
+			case 'B':
+				keys = append(keys, key{kind: keyDown})
+			default:
+				keys = append(keys, key{kind: keyIgnored})
+			}
+			buf = buf[end+1:]
+			continue
+		case buf[0] == '\033':
+			keys = append(keys, key{kind: keyEscape})
+		case buf[0] == '\r' || buf[0] == '\n':
+			keys = append(keys, key{kind: keyEnter})
+		case buf[0] == 0x7f || buf[0] == 0x08:
+			keys = append(keys, key{kind: keyBackspace})
+		case buf[0] == 0x0e: // Ctrl-N
+			keys = append(keys, key{kind: keyDown})
+		case buf[0] == 0x10: // Ctrl-P
+			keys = append(keys, key{kind: keyUp})
+		case buf[0] == 0x15: // Ctrl-U
+			keys = append(keys, key{kind: keyClearQuery})
+		case buf[0] == 0x03 || buf[0] == 0x04: // Ctrl-C and Ctrl-D
+			keys = append(keys, key{kind: keyInterrupt})
+		case buf[0] < 0x20:
+			keys = append(keys, key{kind: keyIgnored})
+		default:
+			r, size := utf8.DecodeRune(buf)
+			keys = append(keys, key{kind: keyRune, r: r})
+			buf = buf[size:]
+			continue
+		}
+		buf = buf[1:]
+	}
+	return keys
+}
+
+// handleKey updates the picker for a single key press.
+func (p *picker) handleKey(k key) pickerAction {
+	switch k.kind {
+	case keyEnter:
+		if len(p.matches) > 0 {
+			return pickerAccept
+		}
+	case keyEscape, keyInterrupt:
+		return pickerCancel
+	case keyUp:
+		if p.selected > 0 {
+			p.selected--
+		}
+	case keyDown:
+		if p.selected < len(p.matches)-1 {
+			p.selected++
+		}
+	case keyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.update()
+		}
+	case keyClearQuery:
+		p.query = p.query[:0]
+		p.update()
+	case keyRune:
+		if unicode.IsPrint(k.r) {
+			p.query = append(p.query, k.r)
+			p.update()
+		}
+	}
+	return pickerContinue
+}
+
+// update searches again with the current query. If the query is invalid, for
+// example an unfinished regular expression, the previous matches are kept.
+func (p *picker) update() {
+	matches, err := p.refine(string(p.query))
+	p.err = err
+	if err != nil {
+		return
+	}
+	p.matches = matches
+	p.selected = 0
+	p.offset = 0
+}
+
+// render draws the picker: the query on the first line, a status line below
+// it, and as many candidates as fit on the screen. On a wide enough screen, the
+// preview of the selected candidate is drawn to the right of the list.
+func (p *picker) render(w io.Writer, width, height int) {
+	listWidth := width
+	if p.preview != nil && width >= minPreviewWidth {
+		listWidth = width / 2
+	}
+	rows := max(height-2, 1)
+	if p.selected < p.offset {
+		p.offset = p.selected
+	}
+	if p.selected >= p.offset+rows {
+		p.offset = p.selected - rows + 1
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\033[H\033[2J")
+
+	status := fmt.Sprintf("  %d/%d", min(len(p.matches), p.selected+1), len(p.matches))
+	if p.err != nil {
+		status += "  " + p.err.Error()
+	}
+	lines := []string{truncate(status, listWidth)}
+
+	visible := p.matches[p.offset:min(p.offset+rows, len(p.matches))]
+	for i, line := range formatRows(visible, listWidth-2) {
+		if p.offset+i == p.selected {
+			line = "> " + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+
+	var preview []string
+	if listWidth < width {
+		preview = p.selectedPreview()
+		for len(lines) < rows+1 {
+			lines = append(lines, "")
+		}
+	}
+	for i, line := range lines {
+		sb.WriteString("\r\n" + line)
+		if listWidth < width {
+			// Move to the column of the preview, the list lines contain escape
+			// sequences so they can't be padded to the same length
+			sb.WriteString(fmt.Sprintf("\033[%dG│ ", listWidth+1))
+			if i < len(preview) {
+				sb.WriteString(truncate(preview[i], width-listWidth-2))
+			}
+		}
+	}
+
+	// Draw the query last, so the cursor ends up behind it
+	sb.WriteString("\033[H" + truncate("> "+string(p.query), width))
+	_, _ = io.WriteString(w, sb.String())
+}
+
+// selectedPreview returns the preview of the selected candidate. Previews are
+// cached, so moving through the list doesn't run the preview command again.
+func (p *picker) selectedPreview() []string {
+	if len(p.matches) == 0 {
+		return nil
+	}
+	path := p.matches[p.selected].Path
+	preview, cached := p.previews[path]
+	if !cached {
+		preview = p.preview(path)
+		p.previews[path] = preview
+	}
+	return preview
+}
+
+// fitPath shortens path from the left so it fits in width characters, and
+// shifts the matched positions along with it.
+func fitPath(path string, positions []int, width int) (string, []int) {
+	runes := []rune(path)
+	if width <= 1 || len(runes) <= width {
+		return path, positions
+	}
+	cut := len(runes) - width + 1
+	shifted := make([]int, 0, len(positions))
+	for _, position := range positions {
+		if position >= cut {
+			shifted = append(shifted, position-cut+1)
+		}
+	}
+	return "…" + string(runes[cut:]), shifted
+}
+
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if width <= 0 || len(runes) <= width {
+		return text
+	}
+	return string(runes[:width])
+}
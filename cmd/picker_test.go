@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("a\033[A\033[B\x0e\x10\r\x7f\x15\x03\033é\033[15~b"))
+
+	expected := []key{
+		{kind: keyRune, r: 'a'},
+		{kind: keyUp},
+		{kind: keyDown},
+		{kind: keyDown},
+		{kind: keyUp},
+		{kind: keyEnter},
+		{kind: keyBackspace},
+		{kind: keyClearQuery},
+		{kind: keyInterrupt},
+		{kind: keyEscape},
+		{kind: keyRune, r: 'é'},
+		{kind: keyIgnored},
+		{kind: keyRune, r: 'b'},
+	}
+	assert.Equal(t, expected, keys)
+}
+
+func TestParseKeys_applicationMode(t *testing.T) {
+	keys := parseKeys([]byte("\033OA\033OB"))
+
+	assert.Equal(t, []key{{kind: keyUp}, {kind: keyDown}}, keys)
+}
+
+func TestPicker_navigate(t *testing.T) {
+	p := newPicker(testCandidates("/a", "/b", "/c"))
+
+	p.handleKey(key{kind: keyUp})
+	assert.Equal(t, 0, p.selected, "Should not move above the first candidate")
+
+	p.handleKey(key{kind: keyDown})
+	p.handleKey(key{kind: keyDown})
+	p.handleKey(key{kind: keyDown})
+	assert.Equal(t, 2, p.selected, "Should not move below the last candidate")
+
+	assert.Equal(t, pickerAccept, p.handleKey(key{kind: keyEnter}))
+	assert.Equal(t, "/c", p.matches[p.selected].Path)
+}
+
+func TestPicker_refine(t *testing.T) {
+	p := newPicker(testCandidates("/api", "/api-legacy", "/web"))
+	p.handleKey(key{kind: keyDown})
+
+	for _, r := range "leg" {
+		p.handleKey(key{kind: keyRune, r: r})
+	}
+	assert.Equal(t, "leg", string(p.query))
+	assert.Equal(t, []string{"/api-legacy"}, paths(p.matches))
+	assert.Equal(t, 0, p.selected, "Selection should reset when the query changes")
+
+	p.handleKey(key{kind: keyClearQuery})
+	assert.Len(t, p.matches, 3, "Clearing the query should show all candidates again")
+}
+
+func TestPicker_invalidQueryKeepsMatches(t *testing.T) {
+	p := newPicker(testCandidates("/api", "/web"))
+	p.refine = func(query string) ([]repository.Match, error) {
+		return nil, errors.New("Invalid regular expression")
+	}
+
+	p.handleKey(key{kind: keyRune, r: '('})
+
+	assert.Len(t, p.matches, 2, "Matches should be kept when the query is invalid")
+	assert.EqualError(t, p.err, "Invalid regular expression")
+}
+
+func TestPicker_cancel(t *testing.T) {
+	p := newPicker(testCandidates("/a"))
+
+	assert.Equal(t, pickerCancel, p.handleKey(key{kind: keyEscape}))
+	assert.Equal(t, pickerCancel, p.handleKey(key{kind: keyInterrupt}))
+}
+
+func TestPicker_enterWithoutMatches(t *testing.T) {
+	p := newPicker(testCandidates())
+
+	assert.Equal(t, pickerContinue, p.handleKey(key{kind: keyEnter}))
+}
+
+func TestPicker_render(t *testing.T) {
+	useColor = false
+	p := newPicker(testCandidates("/a", "/b", "/c", "/d"))
+	p.selected = 3
+
+	var sb strings.Builder
+	p.render(&sb, 80, 4)
+
+	output := sb.String()
+	assert.Contains(t, output, "  4/4")
+	assert.NotContains(t, output, "/a", "Should scroll to keep the selection visible")
+	assert.Contains(t, output, "  /c")
+	assert.Contains(t, output, "> /d")
+	assert.True(t, strings.HasSuffix(output, "\033[H> "), "Should end with the query line")
+}
+
+func TestPicker_renderPreview(t *testing.T) {
+	useColor = false
+	p := newPicker(testCandidates("/a", "/b"))
+	calls := 0
+	p.preview = func(path string) []string {
+		calls++
+		return []string{"preview of " + path}
+	}
+
+	var sb strings.Builder
+	p.render(&sb, 80, 6)
+	p.render(&sb, 80, 6)
+
+	output := sb.String()
+	assert.Contains(t, output, "\033[41G│ preview of /a")
+	assert.Equal(t, 1, calls, "Should cache the preview")
+}
+
+func TestPicker_renderPreviewNarrow(t *testing.T) {
+	useColor = false
+	p := newPicker(testCandidates("/a"))
+	p.preview = func(path string) []string {
+		return []string{"preview of " + path}
+	}
+
+	var sb strings.Builder
+	p.render(&sb, 40, 6)
+
+	assert.NotContains(t, sb.String(), "preview of", "Should not show the preview on a narrow screen")
+}
+
+func TestFitPath(t *testing.T) {
+	path, positions := fitPath("/work/team/api", []int{1, 11, 12}, 6)
+
+	assert.Equal(t, "…m/api", path)
+	assert.Equal(t, []int{3, 4}, positions)
+}
+
+func TestFitPath_fits(t *testing.T) {
+	path, positions := fitPath("/work/api", []int{6}, 80)
+
+	assert.Equal(t, "/work/api", path)
+	assert.Equal(t, []int{6}, positions)
+}
+
+func testCandidates(paths ...string) candidates {
+	options := pathMatches(paths)
+	return candidates{matches: options, mode: repository.ModeFuzzy, refine: filterOptions(options)}
+}
+
+func paths(matches []repository.Match) []string {
+	result := make([]string, len(matches))
+	for i, match := range matches {
+		result[i] = match.Path
+	}
+	return result
+}
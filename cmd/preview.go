@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// pathPlaceholder is replaced with the path of the highlighted project in
+	// the preview command.
+	pathPlaceholder = "{}"
+	// previewDisabled turns the preview off when used as the preview command.
+	previewDisabled = "none"
+	previewTimeout  = time.Second
+
+	readmeLines  = 10
+	commitCount  = 5
+	tabWidth     = 4
+	readmePrefix = "readme"
+)
+
+// previewFunc returns the lines to show in the preview of the project at path.
+type previewFunc func(path string) []string
+
+// escapeSequence matches the ANSI escape sequences a preview command may use
+// for colors, which can't be cut off safely when a line doesn't fit.
+var escapeSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// newPreview returns the preview for the configured command: the built-in
+// preview if there is none, or nil if the preview is disabled.
+func newPreview(command string) previewFunc {
+	switch strings.TrimSpace(command) {
+	case "":
+		return builtinPreview
+	case previewDisabled:
+		return nil
+	}
+	return func(path string) []string {
+		return commandPreview(command, path)
+	}
+}
+
+// builtinPreview shows the head of the README, the last commits and the files
+// at the top level of the project at path.
+func builtinPreview(path string) []string {
+	var lines []string
+	addSection := func(title string, section []string) {
+		if len(section) == 0 {
+			return
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, title)
+		lines = append(lines, section...)
+	}
+
+	readme, head := readmeHead(path)
+	addSection(readme, head)
+	addSection("Recent commits", recentCommits(path))
+	addSection("Files", topLevelFiles(path))
+	return lines
+}
+
+// readmeHead returns the name and the first lines of the README of the project
+// at path.
+func readmeHead(path string) (string, []string) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", nil
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(strings.ToLower(entry.Name()), readmePrefix) {
+			continue
+		}
+		file, err := os.Open(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return "", nil
+		}
+		defer file.Close()
+
+		var lines []string
+		scanner := bufio.NewScanner(file)
+		for len(lines) < readmeLines && scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		return entry.Name(), previewLines(strings.Join(lines, "\n"))
+	}
+	return "", nil
+}
+
+func recentCommits(path string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), previewTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "git", "-C", path, "log", "--oneline", "--no-decorate", "--no-color", "-n", strconv.Itoa(commitCount)).Output()
+	if err != nil {
+		return nil
+	}
+	return previewLines(string(output))
+}
+
+// topLevelFiles lists the files and directories in path, directories first,
+// like ls -p.
+func topLevelFiles(path string) []string {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	var dirs, files []string
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		if entry.IsDir() {
+			dirs = append(dirs, entry.Name()+"/")
+		} else {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(dirs)
+	sort.Strings(files)
+	return append(dirs, files...)
+}
+
+// commandPreview runs the preview command in the project at path. The path
+// replaces the {} placeholder in the command, or is appended to it if there is
+// none.
+func commandPreview(command, path string) []string {
+	quoted := shellQuote(path)
+	if strings.Contains(command, pathPlaceholder) {
+		command = strings.ReplaceAll(command, pathPlaceholder, quoted)
+	} else {
+		command += " " + quoted
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), previewTimeout)
+	defer cancel()
+	preview := exec.CommandContext(ctx, "sh", "-c", command)
+	preview.Dir = path
+	// Show errors in the preview, they're more useful there than nothing
+	output, _ := preview.CombinedOutput()
+	return previewLines(string(output))
+}
+
+// previewLines splits output into lines that can be drawn next to the list,
+// without escape sequences and other control characters that would mess up
+// the screen.
+func previewLines(output string) []string {
+	output = escapeSequence.ReplaceAllString(output, "")
+	output = strings.ReplaceAll(output, "\t", strings.Repeat(" ", tabWidth))
+	output = strings.TrimRight(output, "\r\n")
+	if output == "" {
+		return nil
+	}
+
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, line)
+	}
+	return lines
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPreview(t *testing.T) {
+	assert.NotNil(t, newPreview(""), "Should use the built-in preview without a command")
+	assert.Nil(t, newPreview("none"), "Should disable the preview")
+	assert.NotNil(t, newPreview("ls {}"))
+}
+
+func TestBuiltinPreview(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	project := createProjectTree(t, "cmd")
+	createFiles(t, project, "go.mod", "cmd/main.go")
+	require.NoError(t, os.WriteFile(filepath.Join(project, "README.md"), []byte("# API\n\nThe\tpublic API\n"), 0644))
+	git := func(args ...string) {
+		args = append([]string{"-C", project, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		require.NoError(t, exec.Command("git", args...).Run())
+	}
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "Initial commit")
+
+	preview := builtinPreview(project)
+
+	require.Len(t, preview, 12)
+	assert.Equal(t, []string{"README.md", "# API", "", "The    public API", ""}, preview[:5])
+	assert.Equal(t, "Recent commits", preview[5])
+	assert.Regexp(t, "^[0-9a-f]+ Initial commit$", preview[6])
+	assert.Equal(t, []string{"", "Files", "cmd/", "README.md", "go.mod"}, preview[7:])
+}
+
+func TestBuiltinPreview_notARepository(t *testing.T) {
+	project := t.TempDir()
+	createFiles(t, project, "notes.txt")
+
+	assert.Equal(t, []string{"Files", "notes.txt"}, builtinPreview(project))
+}
+
+func TestCommandPreview(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "it's here")
+	require.NoError(t, os.Mkdir(project, 0755))
+
+	assert.Equal(t, []string{"[" + project + "]"}, commandPreview("printf '[%s]\\n' {}", project))
+	assert.Equal(t, []string{project}, commandPreview("echo", project), "Should append the path without a placeholder")
+	assert.Equal(t, []string{project}, commandPreview("pwd", project)[:1], "Should run in the project")
+}
+
+func TestPreviewLines(t *testing.T) {
+	lines := previewLines("\033[1;32mmain\033[0m\tok\r\nbell\a\n\n")
+
+	assert.Equal(t, []string{"main    ok", "bell"}, lines)
+	assert.Nil(t, previewLines(""))
+}
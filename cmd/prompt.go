@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Menus and prompts are drawn on promptOut, and choices are read from
+// promptIn. Those are the controlling terminal when there is one, so the menu
+// still works when stdout is captured, and stdout only carries output that is
+// meant for scripts.
+var promptIn = bufio.NewReader(os.Stdin)
+var promptOut io.Writer = os.Stderr
+var promptIsTerminal = false
+
+// openPrompt opens the controlling terminal for the prompt. Without one, it
+// falls back to stdin and stderr. The returned function closes the terminal.
+func openPrompt() func() {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		promptIn = bufio.NewReader(os.Stdin)
+		promptOut = os.Stderr
+		promptIsTerminal = isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stderr.Fd())
+		return func() {}
+	}
+
+	promptIn = bufio.NewReader(tty)
+	promptOut = tty
+	promptIsTerminal = true
+	return func() {
+		_ = tty.Close()
+	}
+}
+
+// readChoice reads a single line from the prompt, without surrounding
+// whitespace.
+func readChoice() string {
+	line, _ := promptIn.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// tell writes a message for the user to the prompt.
+func tell(a ...any) {
+	_, _ = fmt.Fprintln(promptOut, a...)
+}
+
+// tellf writes a formatted message for the user to the prompt.
+func tellf(format string, a ...any) {
+	_, _ = fmt.Fprintf(promptOut, format, a...)
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecheerfuldev/gitcd-go/config"
+)
+
+func TestSelectOption(t *testing.T) {
+	output := fakePrompt(t, "2\n")
+
+	choice, chosen := selectOption(testCandidates("/a", "/b"), "Select a project: ")
+
+	assert.True(t, chosen)
+	assert.Equal(t, "/b", choice)
+	assert.Equal(t, "1) /a\n2) /b\nSelect a project: ", output.String())
+}
+
+func TestSelectOption_quit(t *testing.T) {
+	output := fakePrompt(t, "q\n")
+
+	_, chosen := selectOption(testCandidates("/a", "/b"), "Select a project: ")
+
+	assert.False(t, chosen)
+	assert.True(t, strings.HasSuffix(output.String(), "Quitting.\n"))
+}
+
+func TestSelectOption_endOfInput(t *testing.T) {
+	fakePrompt(t, "")
+
+	_, chosen := selectOption(testCandidates("/a", "/b"), "Select a project: ")
+
+	assert.False(t, chosen, "Expected no choice when there is no input")
+}
+
+func TestSelectOption_pages(t *testing.T) {
+	config.Set(config.Config{PageSize: 2})
+	defer config.Set(config.Config{})
+	output := fakePrompt(t, "n\n3\n")
+
+	choice, chosen := selectOption(testCandidates("/a", "/b", "/c"), "Select a project: ")
+
+	assert.True(t, chosen)
+	assert.Equal(t, "/c", choice)
+	assert.Equal(t, "1) /a\n2) /b\nPage 1 of 2, n: next page, p: previous page\nSelect a project: "+
+		"3) /c\nPage 2 of 2, n: next page, p: previous page\nSelect a project: ", output.String())
+}
+
+func TestSelectOption_narrow(t *testing.T) {
+	initTest(t)
+	output := fakePrompt(t, "/api\n-legacy\n1\n")
+
+	choice, chosen := selectOption(testCandidates("/work/api-legacy", "/work/web", "/work/api-v2"), "Select a project: ")
+
+	assert.True(t, chosen)
+	assert.Equal(t, "/work/api-v2", choice)
+	assert.Equal(t, "1) /work/api-legacy\n2) /work/web\n3) /work/api-v2\nSelect a project: "+
+		"1) /work/api-legacy\n2) /work/api-v2\nSelect a project: "+
+		"1) /work/api-v2\nSelect a project: ", output.String())
+}
+
+func TestSelectOption_narrowWithoutMatches(t *testing.T) {
+	initTest(t)
+	output := fakePrompt(t, "/nothing\n2\n")
+
+	choice, chosen := selectOption(testCandidates("/work/api", "/work/web"), "Select a project: ")
+
+	assert.True(t, chosen)
+	assert.Equal(t, "/work/web", choice)
+	assert.Contains(t, output.String(), "No matches, keeping the current list.")
+}
+
+func TestExtractRefinement(t *testing.T) {
+	terms, refining := extractRefinement("/more terms")
+	assert.True(t, refining)
+	assert.Equal(t, []string{"more", "terms"}, terms)
+
+	terms, refining = extractRefinement("-legacy")
+	assert.True(t, refining)
+	assert.Equal(t, []string{"-legacy"}, terms)
+
+	_, refining = extractRefinement("-1")
+	assert.False(t, refining, "Negative numbers are not refinements")
+
+	_, refining = extractRefinement("/")
+	assert.False(t, refining, "A refinement needs terms")
+
+	_, refining = extractRefinement("2")
+	assert.False(t, refining)
+}
+
+// fakePrompt replaces the prompt with input, and returns a builder that
+// collects everything written to it.
+func fakePrompt(t *testing.T, input string) *strings.Builder {
+	output := &strings.Builder{}
+	previousIn, previousOut := promptIn, promptOut
+	promptIn = bufio.NewReader(strings.NewReader(input))
+	promptOut = output
+	useColor = false
+	t.Cleanup(func() {
+		promptIn, promptOut = previousIn, previousOut
+	})
+	return output
+}
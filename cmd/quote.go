@@ -0,0 +1,26 @@
+package cmd
+
+import "strings"
+
+// shellQuote quotes s so the shell reads it as a single word, without
+// expanding anything in it. The result means the same to POSIX shells like
+// bash and zsh as it does to fish: everything is put between single quotes,
+// except for single quotes and backslashes. Those are escaped with a backslash
+// outside the quotes, because fish, unlike POSIX shells, also treats them as
+// escape characters inside single quotes.
+func shellQuote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'':
+			sb.WriteString(`'\''`)
+		case '\\':
+			sb.WriteString(`'\\'`)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('\'')
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hostileNames are directory names that break a cd script or run code when
+// they aren't quoted properly.
+var hostileNames = map[string]string{
+	"plain":                 "api",
+	"spaces":                "my  project",
+	"single_quote":          "it's",
+	"double_quote":          `say "hi"`,
+	"command_substitution":  "$(touch pwned)",
+	"backticks":             "`touch pwned`",
+	"variable":              "$HOME",
+	"backslash":             `back\slash\`,
+	"escaped_quote":         `\'; touch pwned; echo \'`,
+	"newline":               "new\nline",
+	"separators_and_globs":  "*; touch pwned & [a] {b,c} ~",
+	"leading_dash":          "-P",
+	"unicode":               "café ☕",
+	"fish_escapes":          `\x41\n\$`,
+	"semicolon_end_of_line": "x;",
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "'/work/api'", shellQuote("/work/api"))
+	assert.Equal(t, `'/work/it'\''s'`, shellQuote("/work/it's"))
+	assert.Equal(t, `'/work/back'\\'slash'`, shellQuote(`/work/back\slash`))
+	assert.Equal(t, "''", shellQuote(""))
+}
+
+// TestShellQuote_shells sources the cd script for every hostile directory name
+// in every supported shell that is installed, and checks that it changes to
+// that directory without running anything.
+func TestShellQuote_shells(t *testing.T) {
+	shells := map[string][]string{
+		"sh":   {"-c", `. "$1" && pwd`, "sh"},
+		"bash": {"--noprofile", "--norc", "-c", `source "$1" && pwd`, "bash"},
+		"zsh":  {"-f", "-c", `source "$1" && pwd`},
+		"fish": {"--no-config", "-c", "source $argv[1]; and pwd"},
+	}
+	tested := 0
+	for shell, args := range shells {
+		if _, err := exec.LookPath(shell); err != nil {
+			continue
+		}
+		tested++
+		for name, dirName := range hostileNames {
+			t.Run(shell+"/"+name, func(t *testing.T) {
+				root := t.TempDir()
+				dir := filepath.Join(root, dirName)
+				require.NoError(t, os.Mkdir(dir, 0755))
+				script := filepath.Join(root, "change_dir.sh")
+				require.NoError(t, os.WriteFile(script, generateCdScript(dir), 0600))
+				workDir := t.TempDir()
+
+				source := exec.Command(shell, append(args, script)...)
+				source.Dir = workDir
+				output, err := source.Output()
+
+				require.NoError(t, err)
+				assert.Equal(t, dir, strings.TrimSuffix(string(output), "\n"))
+				entries, _ := os.ReadDir(workDir)
+				assert.Empty(t, entries, "Sourcing the script should not run anything")
+			})
+		}
+	}
+	if tested == 0 {
+		t.Skip("no supported shell is installed")
+	}
+}
@@ -18,20 +18,86 @@ import (
 const resetFlag = "reset"
 const scanFlag = "scan"
 const cleanFlag = "clean"
+const regexFlag = "regex"
+const modeFlag = "mode"
+const fullPathFlag = "full-path"
+const pickFlag = "pick"
+const fileFlag = "file"
+const topFlag = "top"
+const colorFlag = "color"
+const printFlag = "print"
+const firstFlag = "first"
+const allFlag = "all"
+const nullFlag = "null"
+const interactiveFlag = "interactive"
+const tmuxFlag = "tmux"
+const tmuxSplitFlag = "tmux-split"
+const handoffPathFlag = "handoff-path"
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use: "gitcd [git repo]",
-	//Args:    cobra.MaximumNArgs(1),
-	Version: "1.1.2",
-	Short:   "",
+	// Search terms can be anything, they're not subcommands
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: completeProjects,
+	Version:           "1.1.2",
+	Short:             "",
 	Long: `GitCD is a CLI tool that lets you easily index and navigate to git projects.
-If you don't provide a repo to search for, a top 10 will be displayed.'`,
+If you don't provide a repo to search for, a top 10 will be displayed, use --top to change its size.
+Multiple search terms have to match in any order, terms starting with - or ! exclude projects.
+If the last of multiple terms contains a /, or the other terms only match without it,
+it selects a subdirectory inside the matched project.
+By default the search is fuzzy, use --mode to search with a regular expression,
+a shell glob, a literal substring or an exact project name instead.
+Use --tmux to open the project in a tmux session, or --tmux-split in a new pane.
+Use --print, --first or --all to use gitcd in scripts, it exits with 2 if
+nothing matches, 3 if the match is ambiguous, 4 if the selection is cancelled
+and 5 if the query is invalid.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		output, err = extractOutputOptions(cmd)
+		if err != nil {
+			tell(err)
+			exitCode = exitError
+			return
+		}
+		handoffPathFlagUsed, err := cmd.Flags().GetBool(handoffPathFlag)
+		if err != nil {
+			tell("Error reading handoff-path flag:", err)
+			exitCode = exitError
+			return
+		}
+		if handoffPathFlagUsed {
+			_, _ = fmt.Fprintln(scriptOut, config.Get().DirChangerPath)
+			return
+		}
+
+		// Scripts get their messages on stderr, rather than on the terminal
+		if output.interactive() {
+			closePrompt := openPrompt()
+			defer closePrompt()
+		}
+
+		clearHandoffs(config.Get().DirChangerPath, time.Now())
+
+		colorSetting, err := cmd.Flags().GetString(colorFlag)
+		if err != nil {
+			tell("Error reading color flag:", err)
+			exitCode = exitError
+			return
+		}
+		useColor, err = colorEnabled(colorSetting, promptIsTerminal)
+		if err != nil {
+			tell(err)
+			exitCode = exitError
+			return
+		}
+
 		resetFlagUsed, err := cmd.Flags().GetBool(resetFlag)
 		if err != nil {
-			fmt.Println("Error reading reset flag:", err)
-			os.Exit(1)
+			tell("Error reading reset flag:", err)
+			exitCode = exitError
+			return
 		}
 		if resetFlagUsed {
 			repository.ResetDatabase()
@@ -41,8 +107,9 @@ If you don't provide a repo to search for, a top 10 will be displayed.'`,
 
 		scanFlagUsed, err := cmd.Flags().GetBool(scanFlag)
 		if err != nil {
-			fmt.Println("Error reading scan flag:", err)
-			os.Exit(1)
+			tell("Error reading scan flag:", err)
+			exitCode = exitError
+			return
 		}
 		if scanFlagUsed {
 			handleScanFlag()
@@ -50,82 +117,446 @@ If you don't provide a repo to search for, a top 10 will be displayed.'`,
 		}
 
 		if len(repository.GetAllProjects()) == 0 {
-			fmt.Println("Your database appears to be empty. Run gitcd with the --scan flag to index your git projects.")
+			tell("Your database appears to be empty. Run gitcd with the --scan flag to index your git projects.")
+			exitCode = exitNoMatch
 			return
 		}
 
 		cleanFlagUsed, err := cmd.Flags().GetBool(cleanFlag)
 		if err != nil {
-			fmt.Println("Error reading clean flag:", err)
-			os.Exit(1)
+			tell("Error reading clean flag:", err)
+			exitCode = exitError
+			return
 		}
 		if cleanFlagUsed {
 			handleCleanFlag()
 			return
 		}
 
+		file, err := cmd.Flags().GetString(fileFlag)
+		if err != nil {
+			tell("Error reading file flag:", err)
+			exitCode = exitError
+			return
+		}
+		if err := validateFilePattern(file); err != nil {
+			tell(err)
+			exitCode = exitInvalidQuery
+			return
+		}
+
+		mode, err := extractMatchMode(cmd)
+		if err != nil {
+			tell(err)
+			exitCode = exitError
+			return
+		}
+
 		if len(args) == 0 {
-			handleMultipleMatches(repository.GiveTopTen())
+			top, err := cmd.Flags().GetInt(topFlag)
+			if err != nil {
+				tell("Error reading top flag:", err)
+				exitCode = exitError
+				return
+			}
+			if top <= 0 {
+				top = config.Get().TopCount
+			}
+			mostUsed := candidates{matches: pathMatches(repository.GiveTop(top)), mode: mode, refine: searchProjects(mode)}
+			handleMatches(mostUsed, destination{file: file}, false)
 			return
 		}
 
-		// If we have arguments, we'll assume it's a regex
-		expression := extractExpression(args)
-		matches, err := repository.GetProjectsRegex(expression)
+		fullPathFlagUsed, err := cmd.Flags().GetBool(fullPathFlag)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			tell("Error reading full-path flag:", err)
+			exitCode = exitError
+			return
+		}
+		if fullPathFlagUsed {
+			repository.SetFullPath(true)
 		}
-		if len(matches) == 0 {
-			fmt.Println("No projects found")
+
+		pickFlagUsed, err := cmd.Flags().GetBool(pickFlag)
+		if err != nil {
+			tell("Error reading pick flag:", err)
+			exitCode = exitError
 			return
 		}
 
-		if len(matches) == 1 {
-			handleSingleMatch(matches[0])
+		matches, terms, subdirectory, err := searchWithSubdirectory(mode, args)
+
+		dest := destination{subdirectory: subdirectory, file: file}
+		if err != nil || len(matches) == 0 {
+			handleNoMatches(mode, terms, err, dest)
 			return
 		}
-		handleMultipleMatches(matches)
+
+		found := candidates{matches: limitResults(matches), query: strings.Join(terms, " "), mode: mode, refine: searchProjects(mode)}
+		handleMatches(found, dest, !pickFlagUsed && topMatchDominates(matches))
 	},
 }
 
-func extractExpression(args []string) string {
-	return strings.Join(args, ".*")
+func extractOutputOptions(cmd *cobra.Command) (outputOptions, error) {
+	options := outputOptions{}
+	flags := map[string]*bool{printFlag: &options.print, firstFlag: &options.first, allFlag: &options.all, nullFlag: &options.null, interactiveFlag: &options.ask, tmuxFlag: &options.tmux, tmuxSplitFlag: &options.split}
+	for name, value := range flags {
+		used, err := cmd.Flags().GetBool(name)
+		if err != nil {
+			return options, fmt.Errorf("Error reading %s flag: %w", name, err)
+		}
+		*value = used
+	}
+	// Paths ending with NUL characters are meant for scripts
+	if options.null && !options.all {
+		options.print = true
+	}
+	if options.split {
+		options.tmux = true
+	}
+	return options, nil
 }
 
-func handleSingleMatch(match string) {
-	err := os.WriteFile(config.Get().DirChangerPath, generateCdScript(match), 0755)
+func extractMatchMode(cmd *cobra.Command) (repository.MatchMode, error) {
+	regexFlagUsed, err := cmd.Flags().GetBool(regexFlag)
 	if err != nil {
-		fmt.Println("Something went wrong while preparing to change directory:", err)
-		_ = os.Remove(config.Get().DirChangerPath)
-		os.Exit(1)
+		return "", fmt.Errorf("Error reading regex flag: %w", err)
 	}
-	project := repository.GetProject(match)
-	project.UpdateCounter()
-	fmt.Println("Changing directory to:", match)
+	if regexFlagUsed {
+		return repository.ModeRegex, nil
+	}
+
+	mode, err := cmd.Flags().GetString(modeFlag)
+	if err != nil {
+		return "", fmt.Errorf("Error reading mode flag: %w", err)
+	}
+	if mode == "" {
+		mode = config.Get().MatchMode
+	}
+	return repository.ParseMatchMode(mode)
 }
 
-func handleMultipleMatches(matches []string) {
-	for i, match := range matches {
-		fmt.Printf("%d) %s\n", i+1, match)
+// topMatchDominates reports whether the first match is ranked so far above
+// the second one that there's no point in asking which one to pick. The
+// thresholds are configured with GITCD_AUTO_JUMP_RATIO and GITCD_AUTO_JUMP_GAP,
+// both are disabled when they are 0.
+func topMatchDominates(matches []repository.Match) bool {
+	if len(matches) < 2 {
+		return len(matches) == 1
 	}
+	top, second := matches[0].Score, matches[1].Score
 
-	fmt.Print("Select a project: ")
-	var choice string
-	_, _ = fmt.Scan(&choice)
+	if gap := config.Get().AutoJumpGap; gap > 0 && top-second >= gap {
+		return true
+	}
+	if ratio := config.Get().AutoJumpRatio; ratio > 0 && top > 0 {
+		return second <= 0 || float64(top)/float64(second) >= ratio
+	}
+	return false
+}
 
-	if choice == "q" || choice == "0" {
-		fmt.Println("Quitting.")
+// pathMatches turns paths into matches without a score or matched characters.
+func pathMatches(paths []string) []repository.Match {
+	matches := make([]repository.Match, len(paths))
+	for i, path := range paths {
+		matches[i] = repository.Match{Path: path}
+	}
+	return matches
+}
+
+// handleNoMatches is called when a query matches nothing, or when it can't be
+// used to search at all. It offers the projects with a similar name instead.
+func handleNoMatches(mode repository.MatchMode, terms []string, err error, dest destination) {
+	code := exitNoMatch
+	if err != nil {
+		tell(err)
+		code = exitInvalidQuery
+	} else {
+		tell("No projects found")
+	}
+
+	suggestions := repository.SuggestProjects(mode, repository.ParseQuery(terms))
+	if len(suggestions) == 0 || !output.interactive() {
+		exitCode = code
 		return
 	}
 
-	index, valid := validateChoice(choice, len(matches))
-	if !valid {
-		fmt.Println("Invalid choice.")
+	tell("Did you mean:")
+	handleMultipleMatches(candidates{matches: pathMatches(suggestions), query: strings.Join(terms, " "), mode: mode, refine: searchProjects(mode)}, dest)
+}
+
+// destination describes where to go inside a matched project.
+type destination struct {
+	subdirectory string
+	file         string
+}
+
+// handleMatches takes the best match when it dominates the others or when the
+// user asked for it, prints all matches when that was asked for, and lets the
+// user choose otherwise. Scripts can't choose, so for them an unclear winner is
+// an error.
+func handleMatches(c candidates, dest destination, dominates bool) {
+	switch {
+	case len(c.matches) == 0:
+		tell("No projects found")
+		exitCode = exitNoMatch
+	case output.all:
+		for _, match := range c.matches {
+			printPaths(match.Path)
+		}
+	case dominates || output.first || (len(c.matches) == 1 && !output.interactive()):
+		handleSingleMatch(c.matches[0].Path, dest)
+	case !output.interactive():
+		tellf("%d projects match, use --first to take the best one or --all to print them all\n", len(c.matches))
+		exitCode = exitAmbiguous
+	default:
+		handleMultipleMatches(c, dest)
+	}
+}
+
+func handleSingleMatch(match string, dest destination) {
+	target, found := resolveSubdirectory(match, dest.subdirectory)
+	if !found {
+		tellf("No directory matching %s found in %s\n", dest.subdirectory, match)
+		target = match
+	}
+
+	if dest.file != "" {
+		directories := findFileDirectories(target, dest.file)
+		switch {
+		case len(directories) == 0:
+			tellf("No files matching %s found in %s\n", dest.file, target)
+			exitCode = exitNoMatch
+			return
+		case len(directories) == 1 || output.first:
+			target = directories[0]
+		case !output.interactive():
+			tellf("%d directories contain files matching %s, use --first to take the closest one\n", len(directories), dest.file)
+			exitCode = exitAmbiguous
+			return
+		default:
+			options := pathMatches(directories)
+			choice, chosen := chooseOption(candidates{matches: options, mode: repository.ModeFuzzy, refine: filterOptions(options)}, "Select a directory: ")
+			if !chosen {
+				cancel()
+				return
+			}
+			target = choice
+		}
+	}
+
+	if output.tmux {
+		if err := openInTmux(match, target, output.split); err != nil {
+			tell(err)
+			exitCode = exitError
+			return
+		}
+		project := repository.GetProject(match)
+		project.UpdateCounter()
+		return
+	}
+	if output.print {
+		printPaths(target)
+		// The shell function changes directory to the printed path, so this
+		// counts as a visit
+		if output.ask {
+			project := repository.GetProject(match)
+			project.UpdateCounter()
+		}
 		return
 	}
+	changeDirectory(match, target)
+}
 
-	handleSingleMatch(matches[index])
+// changeDirectory writes the script that changes the directory to target and
+// runs the hooks of the project in match, and counts the visit of the project.
+func changeDirectory(match, target string) {
+	script := append(generateCdScript(target), hookScript(match, target, projectHooks(match))...)
+	err := writeHandoff(config.Get().DirChangerPath, script)
+	if err != nil {
+		tell("Something went wrong while preparing to change directory:", err)
+		exitCode = exitError
+		return
+	}
+	project := repository.GetProject(match)
+	project.UpdateCounter()
+	tell("Changing directory to:", target)
+}
+
+func handleMultipleMatches(c candidates, dest destination) {
+	choice, chosen := chooseOption(c, "Select a project: ")
+	if !chosen {
+		cancel()
+		return
+	}
+
+	handleSingleMatch(choice, dest)
+}
+
+// limitResults cuts off matches at the configured result limit, if there is
+// one.
+func limitResults(matches []repository.Match) []repository.Match {
+	limit := config.Get().ResultLimit
+	if limit > 0 && len(matches) > limit {
+		return matches[:limit]
+	}
+	return matches
+}
+
+// refineFunc searches again when the user changes the query while picking
+// from a list of candidates.
+type refineFunc func(query string) ([]repository.Match, error)
+
+// candidates is a list of matches the user can pick from, together with the
+// query and match mode that produced them and a way to search again.
+type candidates struct {
+	matches []repository.Match
+	query   string
+	mode    repository.MatchMode
+	refine  refineFunc
+}
+
+// searchProjects refines by searching the whole database again.
+func searchProjects(mode repository.MatchMode) refineFunc {
+	return func(query string) ([]repository.Match, error) {
+		matches, err := repository.SearchProjects(mode, repository.ParseQuery(strings.Fields(query)))
+		return limitResults(matches), err
+	}
+}
+
+// filterOptions refines by fuzzily filtering a fixed list of options.
+func filterOptions(options []repository.Match) refineFunc {
+	return func(query string) ([]repository.Match, error) {
+		matcher, err := repository.NewQueryMatcher(repository.ModeFuzzy, repository.ParseQuery(strings.Fields(query)))
+		if err != nil {
+			return nil, err
+		}
+		result := make([]repository.Match, 0)
+		for _, option := range options {
+			if score, positions, matched := matcher.Match(option.Path); matched {
+				result = append(result, repository.Match{Path: option.Path, Score: score, Positions: positions})
+			}
+		}
+		return result, nil
+	}
+}
+
+// chooseOption lets the user pick one of the candidates. That is done with the
+// configured external picker if there is one, with the interactive picker on
+// a terminal, and with a numbered list otherwise.
+func chooseOption(c candidates, prompt string) (string, bool) {
+	if command := config.Get().Picker; command != "" {
+		choice, chosen, err := runExternalPicker(command, c.matches, c.query)
+		if err != nil {
+			tell(err)
+			exitCode = exitError
+			return "", false
+		}
+		return choice, chosen
+	}
+
+	if promptIsTerminal {
+		if t, err := openTerminal(); err == nil {
+			defer t.restore()
+			return runPicker(t, c)
+		}
+	}
+	return selectOption(c, prompt)
+}
+
+// selectOption shows a numbered list of candidates and asks the user to pick
+// one. Long lists are split into pages, n and p move to the next and previous
+// page. Typing /terms narrows the list down to the candidates that also match
+// those terms, -term removes the candidates that match term. It returns false
+// if the user quits or makes an invalid choice.
+func selectOption(c candidates, prompt string) (string, bool) {
+	options := c.matches
+	if len(options) == 0 {
+		return "", false
+	}
+	page := 0
+
+	for {
+		pageSize := config.Get().PageSize
+		if pageSize <= 0 {
+			pageSize = len(options)
+		}
+		pages := (len(options) + pageSize - 1) / pageSize
+
+		start, end := page*pageSize, min((page+1)*pageSize, len(options))
+		numberWidth := len(strconv.Itoa(end))
+		width := promptWidth()
+		if width > 0 {
+			width -= numberWidth + 2
+		}
+		for i, row := range formatRows(options[start:end], width) {
+			tellf("%*d) %s\n", numberWidth, start+i+1, row)
+		}
+		if pages > 1 {
+			tellf("Page %d of %d, n: next page, p: previous page\n", page+1, pages)
+		}
+
+		tellf("%s", prompt)
+		choice := readChoice()
+
+		if pages > 1 && (choice == "n" || choice == "p") {
+			page = turnPage(page, pages, choice)
+			continue
+		}
+
+		if terms, refining := extractRefinement(choice); refining {
+			narrowed, err := repository.FilterMatches(options, c.mode, repository.ParseQuery(terms))
+			if err != nil {
+				tell(err)
+			} else if len(narrowed) == 0 {
+				tell("No matches, keeping the current list.")
+			} else {
+				options = narrowed
+				page = 0
+			}
+			continue
+		}
+
+		if choice == "q" || choice == "0" {
+			tell("Quitting.")
+			return "", false
+		}
+
+		index, valid := validateChoice(choice, len(options))
+		if !valid {
+			tell("Invalid choice.")
+			return "", false
+		}
+
+		return options[index].Path, true
+	}
+}
+
+// extractRefinement returns the search terms in a choice like /more terms or
+// -term, and whether the choice is a refinement at all. Negative numbers are
+// not considered to be refinements.
+func extractRefinement(choice string) ([]string, bool) {
+	if strings.HasPrefix(choice, "/") {
+		terms := strings.Fields(choice[1:])
+		return terms, len(terms) > 0
+	}
+	if strings.HasPrefix(choice, "-") || strings.HasPrefix(choice, "!") {
+		if _, err := strconv.Atoi(choice); err == nil {
+			return nil, false
+		}
+		terms := strings.Fields(choice)
+		return terms, len(choice) > 1
+	}
+	return nil, false
+}
+
+// turnPage returns the page that n (next) or p (previous) leads to, without
+// moving past the first or last page.
+func turnPage(page, pages int, direction string) int {
+	if direction == "n" {
+		return min(page+1, pages-1)
+	}
+	return max(page-1, 0)
 }
 
 func validateChoice(choice string, numOptions int) (index int, valid bool) {
@@ -136,20 +567,17 @@ func validateChoice(choice string, numOptions int) (index int, valid bool) {
 	return int(convertedChoice - 1), true
 }
 
+// generateCdScript returns the script that the shell sources to change
+// directory to path. It's never executed, so it has no shebang.
 func generateCdScript(path string) []byte {
-	shell, b := os.LookupEnv("SHELL")
-	if !b {
-		shell = "/bin/bash"
-	}
-	return []byte(fmt.Sprintf(
-		`#!%s
-cd %s`, shell, path))
+	return []byte("cd " + shellQuote(path) + "\n")
 }
 
 func handleScanFlag() {
 	root := config.Get().ProjectRootPath
 	if _, err := os.Stat(root); os.IsNotExist(err) {
-		fmt.Printf("$GITCD_PROJECT_HOME (%s) does not exist\n", root)
+		tellf("$GITCD_PROJECT_HOME (%s) does not exist\n", root)
+		exitCode = exitError
 		return
 	}
 
@@ -162,15 +590,19 @@ func handleScanFlag() {
 		StopCharacter:   "✓",
 		StopColors:      []string{"fgGreen"},
 		StopMessage:     " Done!",
+		// Keep stdout for paths, the shell function prints it after the scan
+		Writer: promptOut,
 	}
 	s, err := yacspin.New(cfg)
 	if err != nil {
-		fmt.Println("Error creating spinner:", err)
-		os.Exit(1)
+		tell("Error creating spinner:", err)
+		exitCode = exitError
+		return
 	}
 	if err := s.Start(); err != nil {
-		fmt.Println("Error starting spinner:", err)
-		os.Exit(1)
+		tell("Error starting spinner:", err)
+		exitCode = exitError
+		return
 	}
 
 	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
@@ -183,10 +615,11 @@ func handleScanFlag() {
 		return nil
 	})
 	if err != nil {
-		fmt.Println("Error scanning directories:", err)
+		tell("Error scanning directories:", err)
+		exitCode = exitError
 	}
 	if err := s.Stop(); err != nil {
-		fmt.Println("Error stopping spinner:", err)
+		tell("Error stopping spinner:", err)
 	}
 }
 
@@ -205,19 +638,116 @@ func handleCleanFlag() {
 
 func removeProject(path string) {
 	repository.RemoveProject(path)
-	fmt.Println("Removed:", path)
+	tell("Removed:", path)
 }
 
-func Execute() {
+// isExcludedTerm reports whether arg is a search term like -legacy or -l,
+// rather than a flag. The root command has no shorthand flags that can be
+// combined, so every single dash argument is a search term, unless it's one of
+// the shorthands, like -h.
+func isExcludedTerm(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' || arg[1] == '-' {
+		return false
+	}
+	return len(arg) > 2 || !isShorthand(arg[1:])
+}
+
+// isShorthand reports whether name is the shorthand of a flag of the root
+// command. Cobra only adds the help and version flags when it runs, so they're
+// added here first.
+func isShorthand(name string) bool {
+	rootCmd.InitDefaultHelpFlag()
+	rootCmd.InitDefaultVersionFlag()
+	return rootCmd.Flags().ShorthandLookup(name) != nil || rootCmd.PersistentFlags().ShorthandLookup(name) != nil
+}
+
+// separateExcludedTerms moves excluded search terms like -legacy behind a "--",
+// so they aren't parsed as flags. Only the search terms of the root command
+// are moved: the arguments of subcommands, the values of flags like --file
+// and everything after a "--" are left alone.
+func separateExcludedTerms(args []string) []string {
+	if !searchesProjects(args) {
+		return args
+	}
+	result := make([]string, 0, len(args)+1)
+	terms := make([]string, 0)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			terms = append(terms, args[i+1:]...)
+			break
+		}
+		if isExcludedTerm(arg) {
+			terms = append(terms, arg)
+			continue
+		}
+		result = append(result, arg)
+		if takesValue(arg) && i+1 < len(args) {
+			i++
+			result = append(result, args[i])
+		}
+	}
+	if len(terms) == 0 {
+		return result
+	}
+	return append(append(result, "--"), terms...)
+}
+
+// searchesProjects reports whether args run the root command, rather than one
+// of its subcommands.
+func searchesProjects(args []string) bool {
+	cmd, _, err := rootCmd.Find(args)
+	return err == nil && cmd == rootCmd
+}
+
+// takesValue reports whether arg is a flag of the root command like --file,
+// whose value is the next argument.
+func takesValue(arg string) bool {
+	if !strings.HasPrefix(arg, "--") || strings.Contains(arg, "=") {
+		return false
+	}
+	flag := rootCmd.Flags().Lookup(arg[2:])
+	return flag != nil && flag.NoOptDefVal == ""
+}
+
+// Execute runs the command and returns the code gitcd should exit with.
+func Execute() int {
+	args := os.Args[1:]
+	if isCompletionRequest(args) {
+		rootCmd.SetArgs(separateCompletionTerms(args))
+	} else {
+		rootCmd.SetArgs(separateExcludedTerms(args))
+	}
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		return exitError
 	}
+	return exitCode
 }
 
 func init() {
 	rootCmd.SetVersionTemplate(fmt.Sprintf("gitcd version %s - © Mark Hendriks <thecheerfuldev>\n", rootCmd.Version))
 	rootCmd.Flags().BoolP(scanFlag, "", false, "Scan for git projects in $GITCD_PROJECT_HOME")
 	rootCmd.Flags().BoolP(cleanFlag, "", false, "Remove all git projects that no longer exist")
+	rootCmd.Flags().StringP(modeFlag, "", "", "Match mode: fuzzy, regex, glob, substring or exact (default $GITCD_MATCH_MODE or fuzzy)")
+	rootCmd.Flags().BoolP(regexFlag, "", false, "Search using a regular expression, short for --mode regex")
+	rootCmd.Flags().BoolP(fullPathFlag, "", false, "Match against the full path of a project instead of the path relative to $GITCD_PROJECT_HOME")
+	rootCmd.Flags().BoolP(pickFlag, "", false, "Always show the list of matches, even if there is only one clear winner")
+	rootCmd.Flags().StringP(fileFlag, "", "", "Change directory to the directory containing a file with this name or glob inside the matched project")
+	rootCmd.Flags().IntP(topFlag, "", 0, "Number of projects to list when no repo is provided (default $GITCD_TOP or 10)")
+	rootCmd.Flags().StringP(colorFlag, "", colorAuto, "Colorize listings: auto, always or never")
+	rootCmd.Flags().BoolP(printFlag, "", false, "Print the path of the best match instead of changing directory, without asking anything")
+	rootCmd.Flags().BoolP(firstFlag, "", false, "Take the best match instead of asking which one to take")
+	rootCmd.Flags().BoolP(allFlag, "", false, "Print the paths of all matches instead of changing directory")
+	rootCmd.Flags().BoolP(nullFlag, "", false, "End printed paths with a NUL character instead of a newline, implies --print")
+	rootCmd.Flags().BoolP(interactiveFlag, "", false, "With --print, still ask which project to take and count the visit, used by the shell function of gitcd init")
+	rootCmd.Flags().BoolP(tmuxFlag, "", false, "Switch to the tmux session or window named after the project, or create a session in it")
+	rootCmd.Flags().BoolP(tmuxSplitFlag, "", false, "Split the current tmux window with a pane in the project")
+	rootCmd.Flags().BoolP(handoffPathFlag, "", false, "Print the path of the script gitcd changes directory with, used by gitcd-go-runner.sh")
 	rootCmd.Flags().BoolP(resetFlag, "", false, "Resets the database and scans for git project in $GITCD_PROJECT_HOME")
+
+	_ = rootCmd.RegisterFlagCompletionFunc(modeFlag, completeMatchModes)
+	_ = rootCmd.RegisterFlagCompletionFunc(colorFlag, cobra.FixedCompletions([]cobra.Completion{colorAuto, colorAlways, colorNever}, cobra.ShellCompDirectiveNoFileComp))
+	_ = rootCmd.RegisterFlagCompletionFunc(topFlag, cobra.NoFileCompletions)
+	_ = rootCmd.RegisterFlagCompletionFunc(fileFlag, cobra.NoFileCompletions)
 }
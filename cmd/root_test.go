@@ -1,33 +1,18 @@
 package cmd
 
 import (
+	"flag"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/thecheerfuldev/gitcd-go/config"
 	"github.com/thecheerfuldev/gitcd-go/repository"
 )
 
-func TestExtractExpression_regex(t *testing.T) {
-	input := ".*test.*"
-	expected := ".*test.*"
-	actual := extractExpression([]string{input})
-	assert.Equal(t, expected, actual, "Regex should stay unchanged")
-}
-
-func TestExtractExpression_single(t *testing.T) {
-	input := "test"
-	expected := "test"
-	actual := extractExpression([]string{input})
-	assert.Equal(t, expected, actual, "Single argument should stay unchanged")
-}
-
-func TestExtractExpression_multi(t *testing.T) {
-	input := []string{"foo", "bar", "baz"}
-	expected := "foo.*bar.*baz"
-	actual := extractExpression(input)
-	assert.Equal(t, expected, actual, "Regex should stay unchanged")
-}
+var update = flag.Bool("update", false, "update the golden files in testdata")
 
 func TestHandleSingleMatch(t *testing.T) {
 	initTest(t)
@@ -94,6 +79,114 @@ func TestValidateChoice_SingleOption(t *testing.T) {
 	assert.Equal(t, 0, index)
 }
 
+func TestSeparateExcludedTerms(t *testing.T) {
+	input := []string{"api", "-legacy", "--regex", "!old"}
+	expected := []string{"api", "--regex", "!old", "--", "-legacy"}
+	actual := separateExcludedTerms(input)
+	assert.Equal(t, expected, actual, "Excluded terms should be moved behind --")
+}
+
+func TestSeparateExcludedTerms_existingSeparator(t *testing.T) {
+	input := []string{"-legacy", "api", "--", "-old"}
+	expected := []string{"api", "--", "-legacy", "-old"}
+	actual := separateExcludedTerms(input)
+	assert.Equal(t, expected, actual, "Excluded terms should be merged with the existing separator")
+}
+
+func TestSeparateExcludedTerms_shorthandFlags(t *testing.T) {
+	input := []string{"-h"}
+	expected := []string{"-h"}
+	actual := separateExcludedTerms(input)
+	assert.Equal(t, expected, actual, "Shorthand flags should stay unchanged")
+	assert.Equal(t, []string{"-v"}, separateExcludedTerms([]string{"-v"}))
+
+	input = []string{"api", "-l"}
+	expected = []string{"api", "--", "-l"}
+	assert.Equal(t, expected, separateExcludedTerms(input), "Single letter excluded terms should be moved behind --")
+}
+
+func TestSeparateExcludedTerms_flagValues(t *testing.T) {
+	input := []string{"--file", "-x.sql", "api", "-legacy"}
+	expected := []string{"--file", "-x.sql", "api", "--", "-legacy"}
+	actual := separateExcludedTerms(input)
+	assert.Equal(t, expected, actual, "Values of flags should stay unchanged")
+
+	input = []string{"--file=-x.sql", "--print", "-legacy"}
+	expected = []string{"--file=-x.sql", "--print", "--", "-legacy"}
+	assert.Equal(t, expected, separateExcludedTerms(input))
+}
+
+func TestSeparateExcludedTerms_subcommands(t *testing.T) {
+	for _, input := range [][]string{
+		{"hooks", "-dir"},
+		{"track", "--from", "-old", "-new"},
+		{"init", "bash", "--cmd", "-j"},
+	} {
+		assert.Equal(t, input, separateExcludedTerms(input), "Arguments of subcommands should stay unchanged")
+	}
+}
+
+func TestTopMatchDominates_single(t *testing.T) {
+	config.Set(config.Config{})
+	matches := []repository.Match{{Path: "/a", Score: 10}}
+	assert.True(t, topMatchDominates(matches), "A single match should always dominate")
+}
+
+func TestTopMatchDominates_disabled(t *testing.T) {
+	config.Set(config.Config{})
+	matches := []repository.Match{{Path: "/a", Score: 1000}, {Path: "/b", Score: 1}}
+	assert.False(t, topMatchDominates(matches), "Auto-jump should be disabled by default")
+}
+
+func TestTopMatchDominates_ratio(t *testing.T) {
+	config.Set(config.Config{AutoJumpRatio: 2})
+	assert.True(t, topMatchDominates([]repository.Match{{Path: "/a", Score: 200}, {Path: "/b", Score: 100}}))
+	assert.False(t, topMatchDominates([]repository.Match{{Path: "/a", Score: 199}, {Path: "/b", Score: 100}}))
+	assert.True(t, topMatchDominates([]repository.Match{{Path: "/a", Score: 10}, {Path: "/b", Score: -5}}))
+	assert.False(t, topMatchDominates([]repository.Match{{Path: "/a", Score: -5}, {Path: "/b", Score: -20}}))
+}
+
+func TestTopMatchDominates_gap(t *testing.T) {
+	config.Set(config.Config{AutoJumpGap: 50})
+	assert.True(t, topMatchDominates([]repository.Match{{Path: "/a", Score: 150}, {Path: "/b", Score: 100}}))
+	assert.False(t, topMatchDominates([]repository.Match{{Path: "/a", Score: 149}, {Path: "/b", Score: 100}}))
+}
+
+func TestLimitResults(t *testing.T) {
+	matches := pathMatches([]string{"/a", "/b", "/c"})
+
+	config.Set(config.Config{ResultLimit: 2})
+	assert.Equal(t, matches[:2], limitResults(matches))
+
+	config.Set(config.Config{})
+	assert.Equal(t, matches, limitResults(matches), "No limit by default")
+}
+
+func TestTurnPage(t *testing.T) {
+	assert.Equal(t, 1, turnPage(0, 3, "n"))
+	assert.Equal(t, 2, turnPage(2, 3, "n"), "Should not move past the last page")
+	assert.Equal(t, 1, turnPage(2, 3, "p"))
+	assert.Equal(t, 0, turnPage(0, 3, "p"), "Should not move before the first page")
+}
+
+func TestGenerateCdScript(t *testing.T) {
+	for name, dirName := range hostileNames {
+		t.Run(name, func(t *testing.T) {
+			golden := filepath.Join("testdata", "cd_script", name+".golden")
+
+			script := generateCdScript("/work/" + dirName)
+
+			if *update {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0755))
+				require.NoError(t, os.WriteFile(golden, script, 0644))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(script))
+		})
+	}
+}
+
 func initTest(t *testing.T) {
 	config.Set(config.Config{
 		GitCdHomePath:    t.TempDir(),
@@ -104,3 +197,39 @@ func initTest(t *testing.T) {
 	})
 	_ = repository.Init(config.Get())
 }
+
+func TestExecute_printMalformedDatabase(t *testing.T) {
+	home := t.TempDir()
+	project := createProjectTree(t, ".git")
+	config.Set(config.Config{
+		GitCdHomePath:    home,
+		DatabaseFilePath: filepath.Join(home, "gitcd.db"),
+		DirChangerPath:   filepath.Join(home, "change_dir.test.sh"),
+		MatchMode:        string(repository.ModeFuzzy),
+	})
+	require.NoError(t, os.WriteFile(config.Get().DatabaseFilePath, []byte("broken\n"+project+";1\n"), 0644))
+	t.Cleanup(repository.ResetDatabase)
+	fakeOutput(t, outputOptions{})
+
+	stdout, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	require.NoError(t, err)
+	stderr, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	require.NoError(t, err)
+	previousStdout, previousStderr, previousArgs := os.Stdout, os.Stderr, os.Args
+	os.Stdout, os.Stderr, scriptOut = stdout, stderr, stdout
+	os.Args = []string{"gitcd", "--print", filepath.Base(project)}
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr, os.Args = previousStdout, previousStderr, previousArgs
+		_ = rootCmd.Flags().Set(printFlag, "false")
+	})
+
+	require.NoError(t, repository.Init(config.Get()))
+	assert.Equal(t, exitOK, Execute())
+
+	printed, err := os.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	assert.Equal(t, project+"\n", string(printed), "Should only print the path")
+	warnings, err := os.ReadFile(stderr.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(warnings), "Warning: skipping malformed database entry: broken")
+}
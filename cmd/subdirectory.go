@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+// ignoredDirectories are never descended into when looking for directories
+// inside a project.
+var ignoredDirectories = map[string]bool{
+	".git":         true,
+	".idea":        true,
+	".vscode":      true,
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"build":        true,
+	"dist":         true,
+	"__pycache__":  true,
+}
+
+// maxSubdirectoryDepth is how many levels below the project directories are
+// matched against a subdirectory, so a jump never walks a whole deep tree.
+const maxSubdirectoryDepth = 5
+
+// extractSubdirectory splits the arguments into the search terms and the
+// subdirectory to jump to. If there are multiple arguments and the last one
+// contains a "/", it selects a subdirectory inside the matched project.
+func extractSubdirectory(args []string) (terms []string, subdirectory string) {
+	if len(args) < 2 || !strings.Contains(args[len(args)-1], "/") {
+		return args, ""
+	}
+	if terms, subdirectory, ok := splitLastTerm(args); ok {
+		return terms, subdirectory
+	}
+	return args, ""
+}
+
+// splitLastTerm splits multiple search terms into the terms for the project
+// and the last term, as a subdirectory. Excluded terms are never a
+// subdirectory.
+func splitLastTerm(args []string) (terms []string, subdirectory string, ok bool) {
+	if len(args) < 2 {
+		return args, "", false
+	}
+	last := args[len(args)-1]
+	if strings.HasPrefix(last, "-") || strings.HasPrefix(last, "!") {
+		return args, "", false
+	}
+	return args[:len(args)-1], strings.Trim(last, "/"), true
+}
+
+// splitSubdirectory splits a single search term like billing/svc/invoices
+// into a project query and a subdirectory, at the first "/".
+func splitSubdirectory(arg string) (term string, subdirectory string, ok bool) {
+	term, subdirectory, ok = strings.Cut(strings.Trim(arg, "/"), "/")
+	return term, subdirectory, ok && term != "" && subdirectory != ""
+}
+
+// searchWithSubdirectory searches the projects that match args, and returns
+// the terms that matched them and the subdirectory to jump to. A last term
+// with a "/" selects a subdirectory right away. When nothing matches, a single
+// term like billing/svc/invoices is split at its first "/", and otherwise the
+// last of multiple terms is tried as the subdirectory.
+func searchWithSubdirectory(mode repository.MatchMode, args []string) ([]repository.Match, []string, string, error) {
+	terms, subdirectory := extractSubdirectory(args)
+	matches, err := repository.SearchProjects(mode, repository.ParseQuery(terms))
+	if err != nil || len(matches) > 0 {
+		return matches, terms, subdirectory, err
+	}
+
+	if len(terms) == 1 {
+		if term, tail, ok := splitSubdirectory(terms[0]); ok {
+			splitMatches, splitErr := repository.SearchProjects(mode, repository.ParseQuery([]string{term}))
+			if splitErr == nil && len(splitMatches) > 0 {
+				return splitMatches, terms, tail, nil
+			}
+		}
+	}
+	if subdirectory == "" {
+		if head, tail, ok := splitLastTerm(terms); ok {
+			headMatches, headErr := repository.SearchProjects(mode, repository.ParseQuery(head))
+			if headErr == nil && len(headMatches) > 0 {
+				return headMatches, head, tail, nil
+			}
+		}
+	}
+	return matches, terms, subdirectory, nil
+}
+
+// resolveSubdirectory finds the directory inside project that best matches
+// subdirectory. An existing path is used as is, otherwise the subdirectory is
+// matched fuzzily against all directories in the project. It returns false
+// if nothing matches, or if subdirectory leads out of the project.
+func resolveSubdirectory(project, subdirectory string) (string, bool) {
+	if subdirectory == "" {
+		return project, true
+	}
+	target := filepath.Join(project, subdirectory)
+	if !isInside(project, target) {
+		return "", false
+	}
+	if info, err := os.Stat(target); err == nil && info.IsDir() {
+		return target, true
+	}
+
+	matcher, err := repository.NewQueryMatcher(repository.ModeFuzzy, repository.Query{Include: []string{subdirectory}})
+	if err != nil {
+		return "", false
+	}
+
+	best, bestScore := "", 0
+	_ = filepath.WalkDir(project, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip directories we can't access
+		}
+		if !d.IsDir() || path == project {
+			return nil
+		}
+		if ignoredDirectories[d.Name()] || strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		relative, _ := filepath.Rel(project, path)
+		score, _, matched := matcher.Match(relative)
+		// Prefer the highest score, and the shortest path if scores are equal
+		if matched && (best == "" || score > bestScore || (score == bestScore && len(path) < len(best))) {
+			best, bestScore = path, score
+		}
+		if strings.Count(relative, string(filepath.Separator))+1 >= maxSubdirectoryDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	return best, best != ""
+}
+
+// isInside reports whether path is root or a path inside it.
+func isInside(root, path string) bool {
+	relative, err := filepath.Rel(root, path)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+func TestExtractSubdirectory(t *testing.T) {
+	terms, subdirectory := extractSubdirectory([]string{"billing", "svc/invoices"})
+	assert.Equal(t, []string{"billing"}, terms)
+	assert.Equal(t, "svc/invoices", subdirectory)
+}
+
+func TestExtractSubdirectory_leadingSlash(t *testing.T) {
+	terms, subdirectory := extractSubdirectory([]string{"billing", "/invoices"})
+	assert.Equal(t, []string{"billing"}, terms)
+	assert.Equal(t, "invoices", subdirectory)
+}
+
+func TestExtractSubdirectory_singleArgument(t *testing.T) {
+	terms, subdirectory := extractSubdirectory([]string{"work/billing"})
+	assert.Equal(t, []string{"work/billing"}, terms, "A single argument should stay a search term")
+	assert.Empty(t, subdirectory)
+}
+
+func TestExtractSubdirectory_noSlash(t *testing.T) {
+	terms, subdirectory := extractSubdirectory([]string{"billing", "invoices"})
+	assert.Equal(t, []string{"billing", "invoices"}, terms)
+	assert.Empty(t, subdirectory)
+}
+
+func TestExtractSubdirectory_excludedTerm(t *testing.T) {
+	terms, subdirectory := extractSubdirectory([]string{"billing", "-legacy/api"})
+	assert.Equal(t, []string{"billing", "-legacy/api"}, terms, "An excluded term should not select a subdirectory")
+	assert.Empty(t, subdirectory)
+}
+
+func TestSplitSubdirectory(t *testing.T) {
+	term, subdirectory, ok := splitSubdirectory("billing/svc/invoices")
+	assert.True(t, ok)
+	assert.Equal(t, "billing", term)
+	assert.Equal(t, "svc/invoices", subdirectory)
+
+	_, _, ok = splitSubdirectory("billing/")
+	assert.False(t, ok, "A term without a tail should not be split")
+}
+
+func TestSearchWithSubdirectory(t *testing.T) {
+	initTest(t)
+	t.Cleanup(repository.ResetDatabase)
+	repository.AddProject("/work/billing-service")
+	repository.AddProject("/work/invoices-api")
+
+	matches, terms, subdirectory, err := searchWithSubdirectory(repository.ModeFuzzy, []string{"billing", "invoices"})
+	require.NoError(t, err)
+	assert.Equal(t, "/work/billing-service", matches[0].Path)
+	assert.Equal(t, []string{"billing"}, terms)
+	assert.Equal(t, "invoices", subdirectory, "Expected the last term to select a subdirectory when all terms match nothing")
+
+	matches, _, subdirectory, err = searchWithSubdirectory(repository.ModeFuzzy, []string{"billing/svc/invoices"})
+	require.NoError(t, err)
+	assert.Equal(t, "/work/billing-service", matches[0].Path)
+	assert.Equal(t, "svc/invoices", subdirectory)
+
+	matches, terms, subdirectory, err = searchWithSubdirectory(repository.ModeFuzzy, []string{"api", "invoices"})
+	require.NoError(t, err)
+	assert.Equal(t, "/work/invoices-api", matches[0].Path)
+	assert.Equal(t, []string{"api", "invoices"}, terms, "Expected terms that match together to stay search terms")
+	assert.Empty(t, subdirectory)
+
+	matches, terms, subdirectory, err = searchWithSubdirectory(repository.ModeFuzzy, []string{"payments", "-legacy"})
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+	assert.Equal(t, []string{"payments", "-legacy"}, terms)
+	assert.Empty(t, subdirectory)
+}
+
+func TestResolveSubdirectory(t *testing.T) {
+	initTest(t)
+	project := createProjectTree(t, "svc/invoices", "svc/payments", "docs", "node_modules/invoices")
+
+	target, found := resolveSubdirectory(project, "svc/invoices")
+	assert.True(t, found)
+	assert.Equal(t, filepath.Join(project, "svc", "invoices"), target, "Expected existing path to be used")
+
+	target, found = resolveSubdirectory(project, "inv")
+	assert.True(t, found)
+	assert.Equal(t, filepath.Join(project, "svc", "invoices"), target, "Expected fuzzy match outside ignored directories")
+
+	target, found = resolveSubdirectory(project, "svpay")
+	assert.True(t, found)
+	assert.Equal(t, filepath.Join(project, "svc", "payments"), target)
+
+	_, found = resolveSubdirectory(project, "nothing")
+	assert.False(t, found)
+
+	target, found = resolveSubdirectory(project, "")
+	assert.True(t, found)
+	assert.Equal(t, project, target, "Expected project root without subdirectory")
+}
+
+func TestResolveSubdirectory_outsideProject(t *testing.T) {
+	initTest(t)
+	parent := createProjectTree(t, "api/svc", "secrets")
+	project := filepath.Join(parent, "api")
+
+	_, found := resolveSubdirectory(project, "../secrets")
+	assert.False(t, found, "Should not leave the project")
+
+	target, found := resolveSubdirectory(project, "svc/../svc")
+	assert.True(t, found)
+	assert.Equal(t, filepath.Join(project, "svc"), target)
+}
+
+func TestResolveSubdirectory_depth(t *testing.T) {
+	initTest(t)
+	project := createProjectTree(t, "a/b/c/d/shallow", "a/b/c/d/e/f/deep")
+
+	target, found := resolveSubdirectory(project, "shallow")
+	assert.True(t, found)
+	assert.Equal(t, filepath.Join(project, "a", "b", "c", "d", "shallow"), target)
+
+	_, found = resolveSubdirectory(project, "deep")
+	assert.False(t, found, "Should not look deeper than the depth limit")
+}
+
+func TestIsInside(t *testing.T) {
+	assert.True(t, isInside("/work/api", "/work/api"))
+	assert.True(t, isInside("/work/api", "/work/api/..data"))
+	assert.False(t, isInside("/work/api", "/work"))
+	assert.False(t, isInside("/work/api", "/work/api-old"))
+}
+
+func createProjectTree(t *testing.T, directories ...string) string {
+	project := t.TempDir()
+	for _, directory := range directories {
+		require.NoError(t, os.MkdirAll(filepath.Join(project, directory), 0755))
+	}
+	return project
+}
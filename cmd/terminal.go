@@ -0,0 +1,77 @@
+//go:build linux || darwin
+
+package cmd
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminal is the controlling terminal of the process, switched to raw mode
+// so keys can be read one at a time.
+type terminal struct {
+	in, out *os.File
+	state   *unix.Termios
+}
+
+// openTerminal opens the controlling terminal and switches it to raw mode.
+// Call restore to switch back.
+func openTerminal() (*terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := unix.IoctlGetTermios(int(tty.Fd()), ioctlReadTermios)
+	if err != nil {
+		_ = tty.Close()
+		return nil, err
+	}
+
+	raw := *state
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Oflag &^= unix.OPOST
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(int(tty.Fd()), ioctlWriteTermios, &raw); err != nil {
+		_ = tty.Close()
+		return nil, err
+	}
+
+	return &terminal{in: tty, out: tty, state: state}, nil
+}
+
+// restore switches the terminal back to the mode it was in before it was
+// opened, and closes it.
+func (t *terminal) restore() {
+	_ = unix.IoctlSetTermios(int(t.in.Fd()), ioctlWriteTermios, t.state)
+	_ = t.in.Close()
+}
+
+// size returns the width and height of the terminal, falling back to 80x24
+// if it can't be determined.
+func (t *terminal) size() (width, height int) {
+	winsize, err := unix.IoctlGetWinsize(int(t.out.Fd()), unix.TIOCGWINSZ)
+	if err != nil || winsize.Col == 0 || winsize.Row == 0 {
+		return 80, 24
+	}
+	return int(winsize.Col), int(winsize.Row)
+}
+
+// promptWidth returns the width of the terminal the prompt is drawn on, or 0
+// if the prompt isn't a terminal.
+func promptWidth() int {
+	tty, isFile := promptOut.(*os.File)
+	if !isFile || !promptIsTerminal {
+		return 0
+	}
+	winsize, err := unix.IoctlGetWinsize(int(tty.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0
+	}
+	return int(winsize.Col)
+}
@@ -0,0 +1,29 @@
+//go:build !linux && !darwin
+
+package cmd
+
+import (
+	"errors"
+	"os"
+)
+
+// terminal is not supported on this system, so the numbered list is used
+// instead of the interactive picker.
+type terminal struct {
+	in, out *os.File
+}
+
+func openTerminal() (*terminal, error) {
+	return nil, errors.New("the interactive picker isn't supported on this system")
+}
+
+func (t *terminal) restore() {}
+
+func (t *terminal) size() (width, height int) {
+	return 80, 24
+}
+
+// promptWidth is 0, since the width of the terminal can't be determined.
+func promptWidth() int {
+	return 0
+}
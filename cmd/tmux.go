@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+// tmuxCommand is the tmux binary gitcd runs.
+var tmuxCommand = "tmux"
+
+// tmuxUnsafe matches the characters tmux doesn't allow in session names, it
+// uses them to separate sessions, windows and panes in targets.
+var tmuxUnsafe = strings.NewReplacer(".", "_", ":", "_")
+
+// tmuxName returns the name of the tmux session or window of project. That's
+// the name of the project, or its parent directory and name when another
+// project has the same name, like work/api and oss/api.
+func tmuxName(project string) string {
+	project = filepath.Clean(project)
+	name := filepath.Base(project)
+	for _, other := range repository.GetAllProjects() {
+		if filepath.Clean(other) != project && filepath.Base(other) == name {
+			name = filepath.Join(filepath.Base(filepath.Dir(project)), name)
+			break
+		}
+	}
+	return tmuxUnsafe.Replace(name)
+}
+
+// insideTmux reports whether gitcd runs inside a tmux client.
+func insideTmux() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// openInTmux switches to the tmux session of project, or to a window of the
+// current session with its name. If there is neither, it creates a session for
+// project that starts in target. When target is a subdirectory of a project
+// that has a session or window already, it's opened in a new window. With
+// split, it splits the current window instead, with the new pane in target.
+func openInTmux(project, target string, split bool) error {
+	if split {
+		if !insideTmux() {
+			return errors.New("--tmux-split only works inside tmux")
+		}
+		return runTmux("split-window", "-c", target)
+	}
+
+	name := tmuxName(project)
+	subdirectory := filepath.Clean(target) != filepath.Clean(project)
+	if runTmux("has-session", "-t", "="+name) == nil {
+		if subdirectory {
+			if err := runTmux("new-window", "-t", "="+name+":", "-c", target); err != nil {
+				return err
+			}
+		}
+		if insideTmux() {
+			return runTmux("switch-client", "-t", "="+name)
+		}
+		return attachTmux("attach-session", "-t", "="+name)
+	}
+
+	if !insideTmux() {
+		return attachTmux("new-session", "-s", name, "-c", target)
+	}
+	if hasTmuxWindow(name) {
+		if subdirectory {
+			return runTmux("new-window", "-n", name, "-c", target)
+		}
+		return runTmux("select-window", "-t", ":="+name)
+	}
+	if err := runTmux("new-session", "-d", "-s", name, "-c", target); err != nil {
+		return err
+	}
+	return runTmux("switch-client", "-t", "="+name)
+}
+
+// hasTmuxWindow reports whether the current tmux session has a window called
+// name.
+func hasTmuxWindow(name string) bool {
+	windows, err := exec.Command(tmuxCommand, "list-windows", "-F", "#{window_name}").Output()
+	if err != nil {
+		return false
+	}
+	for _, window := range strings.Split(string(windows), "\n") {
+		if window == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runTmux runs a tmux command that doesn't need the terminal.
+func runTmux(args ...string) error {
+	output, err := exec.Command(tmuxCommand, args...).CombinedOutput()
+	if err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return fmt.Errorf("Error running tmux %s: %s", args[0], message)
+		}
+		return fmt.Errorf("Error running tmux %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// attachTmux runs a tmux command that attaches a client, on the controlling
+// terminal. The shell function captures stdout, so that can't be used.
+func attachTmux(args ...string) error {
+	tmux := exec.Command(tmuxCommand, args...)
+	tmux.Stdin, tmux.Stdout, tmux.Stderr = os.Stdin, os.Stderr, os.Stderr
+	if tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+		defer tty.Close()
+		tmux.Stdin, tmux.Stdout, tmux.Stderr = tty, tty, tty
+	}
+	if err := tmux.Run(); err != nil {
+		return fmt.Errorf("Error running tmux %s: %w", args[0], err)
+	}
+	return nil
+}
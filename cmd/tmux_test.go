@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+// fakeTmux replaces tmux with a script that logs its arguments, knows the
+// given sessions and lists the given windows. It returns a function that reads
+// the logged calls.
+func fakeTmux(t *testing.T, sessions, windows []string) func() []string {
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	tmuxCommand = writeScript(t, dir, "tmux", `echo "$*" >> `+calls+`
+case "$1" in
+  has-session) case " `+strings.Join(sessions, " ")+` " in *" ${3#=} "*) exit 0 ;; esac; echo "can't find session: ${3#=}" >&2; exit 1 ;;
+  list-windows) printf '%s\n' `+strings.Join(append([]string{"zsh"}, windows...), " ")+` ;;
+  split-window) [ -d "$3" ] || exit 1 ;;
+esac`)
+	t.Cleanup(func() {
+		tmuxCommand = "tmux"
+	})
+	return func() []string {
+		content, err := os.ReadFile(calls)
+		require.NoError(t, err)
+		return strings.Split(strings.TrimSpace(string(content)), "\n")
+	}
+}
+
+func TestTmuxName(t *testing.T) {
+	initTest(t)
+	t.Cleanup(repository.ResetDatabase)
+	repository.AddProject("/work/api")
+	repository.AddProject("/work/web")
+
+	assert.Equal(t, "api", tmuxName("/work/api"))
+	assert.Equal(t, "example_com_v1_2", tmuxName("/work/example.com:v1.2"))
+
+	repository.AddProject("/oss/api")
+	assert.Equal(t, "work/api", tmuxName("/work/api"), "Should tell projects with the same name apart")
+	assert.Equal(t, "oss/api", tmuxName("/oss/api"))
+	assert.Equal(t, "web", tmuxName("/work/web"))
+}
+
+func TestOpenInTmux_existingSessionSubdirectory(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	calls := fakeTmux(t, []string{"api"}, nil)
+
+	require.NoError(t, openInTmux("/work/api", "/work/api/svc", false))
+
+	assert.Equal(t, []string{"has-session -t =api", "new-window -t =api: -c /work/api/svc", "switch-client -t =api"}, calls(),
+		"Should open the subdirectory in a new window of the session")
+}
+
+func TestOpenInTmux_existingWindowSubdirectory(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	calls := fakeTmux(t, []string{"main"}, []string{"api"})
+
+	require.NoError(t, openInTmux("/work/api", "/work/api/svc", false))
+
+	assert.Equal(t, []string{"has-session -t =api", "list-windows -F #{window_name}", "new-window -n api -c /work/api/svc"}, calls())
+}
+
+func TestOpenInTmux_existingSession(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	calls := fakeTmux(t, []string{"api"}, nil)
+
+	require.NoError(t, openInTmux("/work/api", "/work/api", false))
+
+	assert.Equal(t, []string{"has-session -t =api", "switch-client -t =api"}, calls())
+}
+
+func TestOpenInTmux_existingWindow(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	calls := fakeTmux(t, []string{"main"}, []string{"api"})
+
+	require.NoError(t, openInTmux("/work/api", "/work/api", false))
+
+	assert.Equal(t, []string{"has-session -t =api", "list-windows -F #{window_name}", "select-window -t :=api"}, calls())
+}
+
+func TestOpenInTmux_newSession(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	calls := fakeTmux(t, []string{"main"}, []string{"api-old"})
+
+	require.NoError(t, openInTmux("/work/api", "/work/api/src", false))
+
+	assert.Equal(t, []string{
+		"has-session -t =api",
+		"list-windows -F #{window_name}",
+		"new-session -d -s api -c /work/api/src",
+		"switch-client -t =api",
+	}, calls())
+}
+
+func TestOpenInTmux_outsideTmux(t *testing.T) {
+	t.Setenv("TMUX", "")
+	calls := fakeTmux(t, []string{"api"}, nil)
+
+	require.NoError(t, openInTmux("/work/api", "/work/api", false))
+	require.NoError(t, openInTmux("/work/web", "/work/web", false))
+
+	assert.Equal(t, []string{
+		"has-session -t =api",
+		"attach-session -t =api",
+		"has-session -t =web",
+		"new-session -s web -c /work/web",
+	}, calls())
+}
+
+func TestOpenInTmux_split(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	calls := fakeTmux(t, nil, nil)
+	project := t.TempDir()
+
+	require.NoError(t, openInTmux(project, project, true))
+	assert.Equal(t, []string{"split-window -c " + project}, calls())
+
+	assert.EqualError(t, openInTmux(project, filepath.Join(project, "missing"), true), "Error running tmux split-window: exit status 1")
+
+	t.Setenv("TMUX", "")
+	assert.EqualError(t, openInTmux(project, project, true), "--tmux-split only works inside tmux")
+}
+
+func TestHandleSingleMatch_tmux(t *testing.T) {
+	initTest(t)
+	t.Cleanup(repository.ResetDatabase)
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	calls := fakeTmux(t, []string{"api"}, nil)
+	project := createProjectTree(t, ".git")
+	repository.AddProject(project)
+	printed := fakeOutput(t, outputOptions{print: true, ask: true, tmux: true})
+	fakePrompt(t, "")
+
+	handleSingleMatch(project, destination{})
+
+	assert.Empty(t, printed.String(), "Should not print the path for the shell function to change directory to")
+	assert.Equal(t, exitOK, exitCode)
+	assert.Contains(t, calls(), "switch-client -t ="+tmuxName(project))
+	assert.Equal(t, 1, repository.GetProject(project).CallCounter)
+}
+
+func TestHandleSingleMatch_tmuxError(t *testing.T) {
+	initTest(t)
+	t.Cleanup(repository.ResetDatabase)
+	t.Setenv("TMUX", "")
+	fakeTmux(t, nil, nil)
+	project := createProjectTree(t, ".git")
+	repository.AddProject(project)
+	fakeOutput(t, outputOptions{tmux: true, split: true})
+	prompt := fakePrompt(t, "")
+
+	handleSingleMatch(project, destination{})
+
+	assert.Equal(t, exitError, exitCode)
+	assert.Contains(t, prompt.String(), "--tmux-split only works inside tmux")
+	assert.Equal(t, 0, repository.GetProject(project).CallCounter)
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+const fromFlag = "from"
+
+// trackCmd counts a visit to a project that was entered without gitcd, like
+// with a plain cd. The shell function of gitcd init --track calls it whenever
+// the directory changes, so it has to be fast: it doesn't run git and only
+// writes the database when a visit was counted.
+var trackCmd = &cobra.Command{
+	Use:   "track <dir>",
+	Short: "Count a visit to the git project that contains dir, for use in shell hooks",
+	Long: `Count a visit to the git project that contains dir, and add the project if
+it isn't known yet. Use --from with the previous directory to not count moving
+around inside the same project.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDirectories,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := cmd.Flags().GetString(fromFlag)
+		if err != nil {
+			tell("Error reading from flag:", err)
+			exitCode = exitError
+			return
+		}
+		trackDirectory(args[0], from)
+	},
+}
+
+// trackDirectory counts a visit to the project that contains dir, unless from
+// is in the same project. It returns the project, or false if dir isn't in a
+// project or no visit was counted.
+func trackDirectory(dir, from string) (string, bool) {
+	root, found := findProjectRoot(dir)
+	if !found {
+		return "", false
+	}
+	if from != "" {
+		if fromRoot, found := findProjectRoot(from); found && fromRoot == root {
+			return "", false
+		}
+	}
+
+	repository.AddProject(root)
+	project := repository.GetProject(root)
+	project.UpdateCounter()
+	return root, true
+}
+
+// findProjectRoot returns the closest directory that contains dir and has a
+// .git directory, or a .git file like worktrees and submodules have. The home
+// directory is never a project, even when it holds a dotfiles repository, so
+// the search stops there.
+func findProjectRoot(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	home, _ := os.UserHomeDir()
+	for {
+		if dir == home {
+			return "", false
+		}
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+func completeDirectories(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveFilterDirs
+}
+
+func init() {
+	trackCmd.Flags().StringP(fromFlag, "", "", "Previous directory, the visit isn't counted if it's in the same project")
+	_ = trackCmd.MarkFlagDirname(fromFlag)
+	rootCmd.AddCommand(trackCmd)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecheerfuldev/gitcd-go/repository"
+)
+
+func TestFindProjectRoot(t *testing.T) {
+	project := createProjectTree(t, ".git", "src/deep")
+
+	root, found := findProjectRoot(filepath.Join(project, "src", "deep"))
+
+	assert.True(t, found)
+	assert.Equal(t, project, root)
+}
+
+func TestFindProjectRoot_worktree(t *testing.T) {
+	worktree := createProjectTree(t, "src")
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: /work/api/.git/worktrees/fix"), 0644))
+
+	root, found := findProjectRoot(filepath.Join(worktree, "src"))
+
+	assert.True(t, found)
+	assert.Equal(t, worktree, root)
+}
+
+func TestFindProjectRoot_notAProject(t *testing.T) {
+	_, found := findProjectRoot(createProjectTree(t, "src"))
+
+	assert.False(t, found)
+}
+
+func TestFindProjectRoot_home(t *testing.T) {
+	home := createProjectTree(t, ".git", "notes")
+	t.Setenv("HOME", home)
+
+	_, found := findProjectRoot(filepath.Join(home, "notes"))
+
+	assert.False(t, found, "Should not take a dotfiles repository in the home directory for a project")
+}
+
+func TestTrackDirectory(t *testing.T) {
+	initTest(t)
+	t.Cleanup(repository.ResetDatabase)
+	project := createProjectTree(t, ".git", "src")
+
+	root, tracked := trackDirectory(filepath.Join(project, "src"), "")
+
+	assert.True(t, tracked)
+	assert.Equal(t, project, root)
+	assert.Equal(t, 1, repository.GetProject(project).CallCounter, "Should add the project and count the visit")
+	assert.False(t, repository.GetProject(project).LastVisit.IsZero())
+}
+
+func TestTrackDirectory_sameProject(t *testing.T) {
+	initTest(t)
+	t.Cleanup(repository.ResetDatabase)
+	project := createProjectTree(t, ".git", "src")
+	repository.AddProject(project)
+
+	_, tracked := trackDirectory(filepath.Join(project, "src"), project)
+
+	assert.False(t, tracked, "Should not count moving around inside a project")
+	assert.Equal(t, 0, repository.GetProject(project).CallCounter)
+}
+
+func TestTrackDirectory_otherProject(t *testing.T) {
+	initTest(t)
+	t.Cleanup(repository.ResetDatabase)
+	project := createProjectTree(t, ".git")
+	previous := createProjectTree(t, ".git")
+
+	_, tracked := trackDirectory(project, previous)
+
+	assert.True(t, tracked)
+	assert.Equal(t, 1, repository.GetProject(project).CallCounter)
+}
+
+func TestTrackDirectory_notAProject(t *testing.T) {
+	initTest(t)
+	t.Cleanup(repository.ResetDatabase)
+	dir := createProjectTree(t, "src")
+
+	_, tracked := trackDirectory(dir, "")
+
+	assert.False(t, tracked)
+	assert.Empty(t, repository.GetAllProjects())
+}
@@ -4,11 +4,16 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strconv"
 )
 
 type Config struct {
 	GitCdHomePath, DatabaseFilePath, DirChangerPath, ProjectRootPath string
-	CaseSensitive                                                    bool
+	HooksFilePath, TagsFilePath, TrustFilePath                       string
+	CaseSensitive, SmartCase, FullPath                               bool
+	MatchMode, Picker, Preview                                       string
+	AutoJumpRatio                                                    float64
+	AutoJumpGap, ResultLimit, PageSize, TopCount, GitTimeout         int
 }
 
 var cfg Config
@@ -31,13 +36,112 @@ func Default() Config {
 		c.CaseSensitive = false
 	}
 
+	lookupEnv, exists = os.LookupEnv("GITCD_SMART_CASE")
+	if exists {
+		c.SmartCase = lookupEnv == "true"
+	} else {
+		c.SmartCase = false
+	}
+
+	lookupEnv, exists = os.LookupEnv("GITCD_FULL_PATH")
+	if exists {
+		c.FullPath = lookupEnv == "true"
+	} else {
+		c.FullPath = false
+	}
+
+	lookupEnv, exists = os.LookupEnv("GITCD_MATCH_MODE")
+	if exists {
+		c.MatchMode = lookupEnv
+	} else {
+		c.MatchMode = "fuzzy"
+	}
+
+	lookupEnv, exists = os.LookupEnv("GITCD_PICKER")
+	if exists {
+		c.Picker = lookupEnv
+	}
+
+	lookupEnv, exists = os.LookupEnv("GITCD_PREVIEW")
+	if exists {
+		c.Preview = lookupEnv
+	}
+
+	lookupEnv, exists = os.LookupEnv("GITCD_AUTO_JUMP_RATIO")
+	if exists {
+		c.AutoJumpRatio, _ = strconv.ParseFloat(lookupEnv, 64)
+	}
+
+	lookupEnv, exists = os.LookupEnv("GITCD_AUTO_JUMP_GAP")
+	if exists {
+		c.AutoJumpGap, _ = strconv.Atoi(lookupEnv)
+	}
+
+	c.ResultLimit = 0
+	lookupEnv, exists = os.LookupEnv("GITCD_RESULT_LIMIT")
+	if exists {
+		c.ResultLimit, _ = strconv.Atoi(lookupEnv)
+	}
+
+	c.PageSize = 20
+	lookupEnv, exists = os.LookupEnv("GITCD_PAGE_SIZE")
+	if exists {
+		if pageSize, err := strconv.Atoi(lookupEnv); err == nil && pageSize > 0 {
+			c.PageSize = pageSize
+		}
+	}
+
+	c.TopCount = 10
+	lookupEnv, exists = os.LookupEnv("GITCD_TOP")
+	if exists {
+		if topCount, err := strconv.Atoi(lookupEnv); err == nil && topCount > 0 {
+			c.TopCount = topCount
+		}
+	}
+
+	c.GitTimeout = 300
+	lookupEnv, exists = os.LookupEnv("GITCD_GIT_TIMEOUT")
+	if exists {
+		if gitTimeout, err := strconv.Atoi(lookupEnv); err == nil {
+			c.GitTimeout = gitTimeout
+		}
+	}
+
 	c.GitCdHomePath = filepath.Join(homeDir, ".config", "gitcd")
 	c.DatabaseFilePath = filepath.Join(c.GitCdHomePath, "gitcd.db")
-	c.DirChangerPath = filepath.Join(c.GitCdHomePath, "change_dir.sh")
+	c.HooksFilePath = filepath.Join(c.GitCdHomePath, "hooks")
+	c.TagsFilePath = filepath.Join(c.GitCdHomePath, "tags")
+	c.TrustFilePath = filepath.Join(c.GitCdHomePath, "trusted")
+	c.DirChangerPath = filepath.Join(c.GitCdHomePath, "change_dir."+handoffKey()+".sh")
 
 	return c
 }
 
+// handoffKey identifies the invocation that the script that changes directory
+// is meant for, so shells that jump at the same time each get their own. That's
+// the token the wrapper passes in GITCD_HANDOFF, or the PID of the shell that
+// started gitcd if there is none.
+func handoffKey() string {
+	token, exists := os.LookupEnv("GITCD_HANDOFF")
+	if exists && validHandoffToken(token) {
+		return token
+	}
+	return strconv.Itoa(os.Getppid())
+}
+
+// validHandoffToken reports whether token can safely be used in a file name.
+func validHandoffToken(token string) bool {
+	if token == "" {
+		return false
+	}
+	for _, r := range token {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
 func Set(c Config) {
 	cfg = c
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"os"
 	"path"
+	"strconv"
 	"testing"
 )
 
@@ -22,12 +23,24 @@ func TestDefault(t *testing.T) {
 	expected = path.Join(cfg.GitCdHomePath, "gitcd.db")
 	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
 
+	actual = cfg.HooksFilePath
+	expected = path.Join(cfg.GitCdHomePath, "hooks")
+	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
+
+	actual = cfg.TagsFilePath
+	expected = path.Join(cfg.GitCdHomePath, "tags")
+	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
+
+	actual = cfg.TrustFilePath
+	expected = path.Join(cfg.GitCdHomePath, "trusted")
+	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
+
 	actual = cfg.ProjectRootPath
 	expected = projectRoot
 	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
 
 	actual = cfg.DirChangerPath
-	expected = path.Join(cfg.GitCdHomePath, "change_dir.sh")
+	expected = path.Join(cfg.GitCdHomePath, "change_dir."+strconv.Itoa(os.Getppid())+".sh")
 	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
 
 }
@@ -89,3 +102,153 @@ func TestGet(t *testing.T) {
 	expected := cfg
 	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
 }
+
+func TestDefaultWithSmartCase(t *testing.T) {
+	_ = os.Setenv("GITCD_SMART_CASE", "true")
+	defer os.Unsetenv("GITCD_SMART_CASE")
+
+	cfg := Default()
+	actual := cfg.SmartCase
+	expected := true
+	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
+}
+
+func TestDefaultWithoutSmartCase(t *testing.T) {
+	_ = os.Unsetenv("GITCD_SMART_CASE")
+
+	cfg := Default()
+	actual := cfg.SmartCase
+	expected := false
+	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
+}
+
+func TestDefaultWithFullPath(t *testing.T) {
+	_ = os.Setenv("GITCD_FULL_PATH", "true")
+	defer os.Unsetenv("GITCD_FULL_PATH")
+
+	cfg := Default()
+	actual := cfg.FullPath
+	expected := true
+	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
+}
+
+func TestDefaultWithMatchMode(t *testing.T) {
+	_ = os.Setenv("GITCD_MATCH_MODE", "glob")
+	defer os.Unsetenv("GITCD_MATCH_MODE")
+
+	cfg := Default()
+	actual := cfg.MatchMode
+	expected := "glob"
+	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
+}
+
+func TestDefaultWithoutMatchMode(t *testing.T) {
+	_ = os.Unsetenv("GITCD_MATCH_MODE")
+
+	cfg := Default()
+	actual := cfg.MatchMode
+	expected := "fuzzy"
+	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
+}
+
+func TestDefaultWithAutoJump(t *testing.T) {
+	_ = os.Setenv("GITCD_AUTO_JUMP_RATIO", "2.5")
+	_ = os.Setenv("GITCD_AUTO_JUMP_GAP", "40")
+	defer os.Unsetenv("GITCD_AUTO_JUMP_RATIO")
+	defer os.Unsetenv("GITCD_AUTO_JUMP_GAP")
+
+	cfg := Default()
+	assert.Equal(t, 2.5, cfg.AutoJumpRatio, "actual %v, expected %v", cfg.AutoJumpRatio, 2.5)
+	assert.Equal(t, 40, cfg.AutoJumpGap, "actual %v, expected %v", cfg.AutoJumpGap, 40)
+}
+
+func TestDefaultWithoutAutoJump(t *testing.T) {
+	_ = os.Unsetenv("GITCD_AUTO_JUMP_RATIO")
+	_ = os.Setenv("GITCD_AUTO_JUMP_GAP", "invalid")
+	defer os.Unsetenv("GITCD_AUTO_JUMP_GAP")
+
+	cfg := Default()
+	assert.Equal(t, 0.0, cfg.AutoJumpRatio, "actual %v, expected %v", cfg.AutoJumpRatio, 0.0)
+	assert.Equal(t, 0, cfg.AutoJumpGap, "actual %v, expected %v", cfg.AutoJumpGap, 0)
+}
+
+func TestDefaultWithLimits(t *testing.T) {
+	_ = os.Setenv("GITCD_RESULT_LIMIT", "50")
+	_ = os.Setenv("GITCD_PAGE_SIZE", "15")
+	_ = os.Setenv("GITCD_TOP", "25")
+	defer os.Unsetenv("GITCD_RESULT_LIMIT")
+	defer os.Unsetenv("GITCD_PAGE_SIZE")
+	defer os.Unsetenv("GITCD_TOP")
+
+	cfg := Default()
+	assert.Equal(t, 50, cfg.ResultLimit, "actual %v, expected %v", cfg.ResultLimit, 50)
+	assert.Equal(t, 15, cfg.PageSize, "actual %v, expected %v", cfg.PageSize, 15)
+	assert.Equal(t, 25, cfg.TopCount, "actual %v, expected %v", cfg.TopCount, 25)
+}
+
+func TestDefaultWithoutLimits(t *testing.T) {
+	_ = os.Unsetenv("GITCD_RESULT_LIMIT")
+	_ = os.Setenv("GITCD_PAGE_SIZE", "0")
+	_ = os.Unsetenv("GITCD_TOP")
+	defer os.Unsetenv("GITCD_PAGE_SIZE")
+
+	cfg := Default()
+	assert.Equal(t, 0, cfg.ResultLimit, "actual %v, expected %v", cfg.ResultLimit, 0)
+	assert.Equal(t, 20, cfg.PageSize, "actual %v, expected %v", cfg.PageSize, 20)
+	assert.Equal(t, 10, cfg.TopCount, "actual %v, expected %v", cfg.TopCount, 10)
+}
+
+func TestDefaultWithPicker(t *testing.T) {
+	_ = os.Setenv("GITCD_PICKER", "fzf --height 40%")
+	defer os.Unsetenv("GITCD_PICKER")
+
+	cfg := Default()
+	actual := cfg.Picker
+	expected := "fzf --height 40%"
+	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
+}
+
+func TestDefaultWithPreview(t *testing.T) {
+	_ = os.Setenv("GITCD_PREVIEW", "tree -L 1 {}")
+	defer os.Unsetenv("GITCD_PREVIEW")
+
+	cfg := Default()
+	actual := cfg.Preview
+	expected := "tree -L 1 {}"
+	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
+}
+
+func TestDefaultWithHandoffToken(t *testing.T) {
+	_ = os.Setenv("GITCD_HANDOFF", "1234-5678")
+	defer os.Unsetenv("GITCD_HANDOFF")
+
+	cfg := Default()
+	actual := cfg.DirChangerPath
+	expected := path.Join(cfg.GitCdHomePath, "change_dir.1234-5678.sh")
+	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
+}
+
+func TestDefaultWithInvalidHandoffToken(t *testing.T) {
+	_ = os.Setenv("GITCD_HANDOFF", "../../.bashrc")
+	defer os.Unsetenv("GITCD_HANDOFF")
+
+	cfg := Default()
+	actual := cfg.DirChangerPath
+	expected := path.Join(cfg.GitCdHomePath, "change_dir."+strconv.Itoa(os.Getppid())+".sh")
+	assert.Equal(t, expected, actual, "actual %v, expected %v", actual, expected)
+}
+
+func TestDefaultWithGitTimeout(t *testing.T) {
+	_ = os.Setenv("GITCD_GIT_TIMEOUT", "0")
+	defer os.Unsetenv("GITCD_GIT_TIMEOUT")
+
+	cfg := Default()
+	assert.Equal(t, 0, cfg.GitTimeout, "actual %v, expected %v", cfg.GitTimeout, 0)
+}
+
+func TestDefaultWithoutGitTimeout(t *testing.T) {
+	_ = os.Unsetenv("GITCD_GIT_TIMEOUT")
+
+	cfg := Default()
+	assert.Equal(t, 300, cfg.GitTimeout, "actual %v, expected %v", cfg.GitTimeout, 300)
+}
@@ -1,8 +1,24 @@
 #!/usr/bin/env bash
 
-gitcd-go "$@"
+# Every run gets its own script to change directory with, so shells that jump at
+# the same time, or twice from the same shell, don't change to each other's
+# project. gitcd knows where its home directory is, so it tells where the
+# script goes.
+__gitcd_token="$$-$RANDOM$RANDOM"
+__gitcd_handoff="$(GITCD_HANDOFF="$__gitcd_token" gitcd-go --handoff-path)"
+__gitcd_status=$?
 
-if [ -f ~/.config/gitcd/change_dir.sh ]; then
-  source ~/.config/gitcd/change_dir.sh
-  rm ~/.config/gitcd/change_dir.sh
+if [ "$__gitcd_status" -eq 0 ] && [ -n "$__gitcd_handoff" ]; then
+  rm -f "$__gitcd_handoff"
+  GITCD_HANDOFF="$__gitcd_token" gitcd-go "$@"
+  __gitcd_status=$?
+
+  if [ "$__gitcd_status" -eq 0 ] && [ -f "$__gitcd_handoff" ]; then
+    source "$__gitcd_handoff"
+  fi
+  rm -f "$__gitcd_handoff"
 fi
+
+# Expand the status before unsetting it, without touching the positional
+# parameters of the shell that sources this script
+eval "unset __gitcd_token __gitcd_handoff __gitcd_status; return $__gitcd_status 2>/dev/null || exit $__gitcd_status"
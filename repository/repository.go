@@ -2,39 +2,54 @@ package repository
 
 import (
 	"bufio"
-	"errors"
 	"fmt"
 	"os"
-	"regexp"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/thecheerfuldev/gitcd-go/config"
 )
 
 var database = map[string]Project{}
 var isModified = false
+
+// touched holds the projects this run added, visited or removed, with the
+// number of visits it counted. Only those are written back to the database.
+var touched = map[string]int{}
+
+// reset is set when this run replaces the whole database.
+var reset = false
 var cfg config.Config
 
 type Project struct {
 	Path        string
 	CallCounter int
+	LastVisit   time.Time
 }
 
 func (project *Project) UpdateCounter() {
 	project.CallCounter += 1
+	project.LastVisit = time.Now()
 	SaveProject(*project)
+	touched[project.Path]++
 }
 
 func (project *Project) saveString() string {
-	return fmt.Sprintf("%v;%v", project.Path, project.CallCounter)
+	if project.LastVisit.IsZero() {
+		return fmt.Sprintf("%v;%v", project.Path, project.CallCounter)
+	}
+	return fmt.Sprintf("%v;%v;%v", project.Path, project.CallCounter, project.LastVisit.Unix())
 }
 
-func addProjectFromDb(path string, callCount int) {
+func addProjectFromDb(path string, callCount int, lastVisit time.Time) {
 	project := Project{
 		Path:        path,
 		CallCounter: callCount,
+		LastVisit:   lastVisit,
 	}
 
 	database[path] = project
@@ -53,9 +68,17 @@ func AddProject(path string) {
 	}
 
 	database[path] = project
+	touch(path)
 	isModified = true
 }
 
+// touch marks path as changed by this run.
+func touch(path string) {
+	if _, found := touched[path]; !found {
+		touched[path] = 0
+	}
+}
+
 func GetAllProjects() []string {
 	result := make([]string, len(database))
 
@@ -70,45 +93,90 @@ func GetAllProjects() []string {
 
 func RemoveProject(key string) {
 	delete(database, key)
+	touch(key)
 	isModified = true
 }
 
+// GetProjectsRegex returns all projects whose path matches the regular
+// expression in input.
 func GetProjectsRegex(input string) ([]string, error) {
-	projects := make([]Project, 0)
+	return GetProjectsMatching(ModeRegex, Query{Include: []string{input}})
+}
+
+// Match is a project that matched a query, together with its rank and the
+// indexes of the matched characters in its path.
+type Match struct {
+	Path      string
+	Score     int
+	Positions []int
+}
 
-	if caseInsensitive() {
-		input = "(?i)" + input
+// GetProjectsMatching returns all projects that match query in the given
+// mode, ranked by SearchProjects.
+func GetProjectsMatching(mode MatchMode, query Query) ([]string, error) {
+	matches, err := SearchProjects(mode, query)
+	if err != nil {
+		return nil, err
 	}
+	return matchPaths(matches), nil
+}
 
-	compile, err := regexp.Compile(input)
+// SearchProjects returns all projects that match query in the given mode.
+func SearchProjects(mode MatchMode, query Query) ([]Match, error) {
+	matcher, err := NewQueryMatcher(mode, query)
 	if err != nil {
-		return nil, errors.New("Invalid regular expression")
+		return nil, err
 	}
+	return rankProjects(matcher), nil
+}
+
+// GetProjects returns all projects matched by matcher, ranked by rankProjects.
+func GetProjects(matcher Matcher) []string {
+	return matchPaths(rankProjects(matcher))
+}
+
+// rankProjects returns all projects matched by matcher. The results are ranked
+// by a combination of the match score and the call counter of each project.
+func rankProjects(matcher Matcher) []Match {
+	matches := make([]Match, 0)
 
 	for key, project := range database {
-		if compile.MatchString(key) {
-			projects = append(projects, project)
+		text := matchText(key)
+		score, positions, matched := matcher.Match(text)
+		if !matched {
+			continue
+		}
+		// The text that was matched is always a suffix of the path
+		offset := len([]rune(key)) - len([]rune(text))
+		for i := range positions {
+			positions[i] += offset
 		}
+		matches = append(matches, Match{Path: key, Score: score + usageScore(project.CallCounter), Positions: positions})
 	}
 
-	sort.Slice(projects, func(i, j int) bool {
-		if projects[i].CallCounter != projects[j].CallCounter {
-			return projects[i].CallCounter > projects[j].CallCounter
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
 		}
-		// CallCounters are equal, sort by Path, alphabetically, hence the "<"
-		return projects[i].Path < projects[j].Path
+		// Scores are equal, sort by Path, alphabetically, hence the "<"
+		return matches[i].Path < matches[j].Path
 	})
 
+	return matches
+}
+
+func matchPaths(matches []Match) []string {
 	result := make([]string, 0)
 
-	for _, value := range projects {
-		result = append(result, value.Path)
+	for _, match := range matches {
+		result = append(result, match.Path)
 	}
-	return result, nil
+	return result
 }
 
 func SaveProject(project Project) {
 	database[project.Path] = project
+	touch(project.Path)
 	isModified = true
 }
 
@@ -118,11 +186,23 @@ func GetProject(key string) Project {
 }
 
 func readDatabase() {
-	dbFile, err := os.Open(cfg.DatabaseFilePath)
+	projects, err := readDatabaseFile(cfg.DatabaseFilePath)
 	if err != nil {
-		fmt.Println("Error opening database file:", err)
+		fmt.Fprintln(os.Stderr, err)
 		return
 	}
+	for _, project := range projects {
+		addProjectFromDb(project.Path, project.CallCounter, project.LastVisit)
+	}
+}
+
+// readDatabaseFile reads the projects in the database file at path. Malformed
+// entries are skipped with a warning.
+func readDatabaseFile(path string) (map[string]Project, error) {
+	dbFile, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening database file: %w", err)
+	}
 	defer dbFile.Close()
 
 	var lines []string
@@ -133,10 +213,10 @@ func readDatabase() {
 	}
 
 	if err := scanner.Err(); err != nil {
-		fmt.Println("Error reading database file:", err)
-		return
+		return nil, fmt.Errorf("Error reading database file: %w", err)
 	}
 
+	projects := map[string]Project{}
 	for _, projectText := range lines {
 
 		if projectText == "" {
@@ -145,20 +225,28 @@ func readDatabase() {
 
 		split := strings.Split(projectText, ";")
 		if len(split) < 2 {
-			fmt.Println("Warning: skipping malformed database entry:", projectText)
+			fmt.Fprintln(os.Stderr, "Warning: skipping malformed database entry:", projectText)
 			continue
 		}
 		i, err := strconv.ParseInt(split[1], 10, 0)
 		if err != nil {
-			fmt.Println("Warning: skipping entry with invalid call count:", projectText)
+			fmt.Fprintln(os.Stderr, "Warning: skipping entry with invalid call count:", projectText)
 			continue
 		}
 		callCount := int(i)
 		if split[0] == "" {
 			continue
 		}
-		addProjectFromDb(split[0], callCount)
+		// The last visit was added later, so older databases don't have it
+		var lastVisit time.Time
+		if len(split) > 2 {
+			if seconds, err := strconv.ParseInt(split[2], 10, 64); err == nil {
+				lastVisit = time.Unix(seconds, 0)
+			}
+		}
+		projects[split[0]] = Project{Path: split[0], CallCounter: callCount, LastVisit: lastVisit}
 	}
+	return projects, nil
 }
 
 func Init(config config.Config) error {
@@ -178,28 +266,91 @@ func Init(config config.Config) error {
 	return nil
 }
 
+// WriteChangesToDatabase writes the projects this run added, visited or
+// removed to the database file. Other runs, like the track hooks of other
+// shells, may have changed the file since it was read, so the changes are
+// applied to the file as it is now, while holding a lock on it. The file is
+// replaced at once, so nobody ever reads it half written.
 func WriteChangesToDatabase() {
 	if !isModified {
 		return
 	}
 
-	databaseFile, err := os.OpenFile(cfg.DatabaseFilePath, os.O_TRUNC|os.O_WRONLY, 0644)
+	unlock, err := lockDatabase()
 	if err != nil {
-		fmt.Println("Error opening database file for writing:", err)
+		fmt.Fprintln(os.Stderr, "Error locking database file:", err)
 		return
 	}
-	defer databaseFile.Close()
+	defer unlock()
 
-	for _, project := range database {
-		_, err := databaseFile.WriteString(project.saveString() + "\n")
+	projects := map[string]Project{}
+	if !reset {
+		projects, err = readDatabaseFile(cfg.DatabaseFilePath)
 		if err != nil {
-			fmt.Println("Error writing to database file:", err)
+			fmt.Fprintln(os.Stderr, err)
 			return
 		}
 	}
+	mergeChanges(projects)
+
+	err = replaceFile(cfg.DatabaseFilePath, projects)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing to database file:", err)
+		return
+	}
+	touched = map[string]int{}
+	reset = false
+	isModified = false
+}
+
+// mergeChanges applies the changes of this run to projects, as read from the
+// database file. Visits are added to the counters in the file, so visits
+// counted by other runs in the meantime aren't lost.
+func mergeChanges(projects map[string]Project) {
+	for path, visits := range touched {
+		project, exists := database[path]
+		if !exists {
+			delete(projects, path)
+			continue
+		}
+		if stored, found := projects[path]; found {
+			project.CallCounter = stored.CallCounter + visits
+			if stored.LastVisit.After(project.LastVisit) {
+				project.LastVisit = stored.LastVisit
+			}
+		}
+		projects[path] = project
+	}
 }
 
-func GiveTopTen() []string {
+// replaceFile writes projects to a temporary file next to path, and renames it
+// over path.
+func replaceFile(path string, projects map[string]Project) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	writer := bufio.NewWriter(file)
+	for _, project := range projects {
+		_, _ = writer.WriteString(project.saveString() + "\n")
+	}
+	err = writer.Flush()
+	if err == nil {
+		err = file.Chmod(0644)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// GiveTop returns the n most used projects.
+func GiveTop(n int) []string {
 	projects := make([]Project, 0)
 	for _, project := range database {
 		projects = append(projects, project)
@@ -213,27 +364,78 @@ func GiveTopTen() []string {
 		return projects[i].Path < projects[j].Path
 	})
 
-	maxSize := 10
+	maxSize := n
 
 	if len(projects) < maxSize {
 		maxSize = len(projects)
 	}
 
-	topTenProjects := make([]string, maxSize)
+	topProjects := make([]string, maxSize)
 
 	for i, project := range projects[:maxSize] {
-		topTenProjects[i] = project.Path
+		topProjects[i] = project.Path
 	}
 
-	return topTenProjects
+	return topProjects
 
 }
 
-func caseInsensitive() bool {
-	return !cfg.CaseSensitive
+// matchText returns the part of path that queries are matched against. Unless
+// full path matching is enabled, that is the path relative to the project root,
+// so the directories above it don't match every query.
+func matchText(path string) string {
+	if cfg.FullPath || cfg.ProjectRootPath == "" {
+		return path
+	}
+	relative, err := filepath.Rel(cfg.ProjectRootPath, path)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return path
+	}
+	if relative == "." {
+		return filepath.Base(path)
+	}
+	return relative
+}
+
+// SetFullPath determines whether queries are matched against the full path of
+// a project, instead of the path relative to the project root.
+func SetFullPath(fullPath bool) {
+	cfg.FullPath = fullPath
+}
+
+// caseSensitive determines whether query should be matched case-sensitively.
+// With smart case enabled, a query is only case-sensitive when it contains an
+// uppercase letter, otherwise GITCD_CASE_SENSITIVE decides.
+func caseSensitive(mode MatchMode, query string) bool {
+	if cfg.SmartCase {
+		return hasUppercase(mode, query)
+	}
+	return cfg.CaseSensitive
+}
+
+// hasUppercase reports whether query contains an uppercase letter. In regex
+// mode, escape sequences like \S and \W don't count.
+func hasUppercase(mode MatchMode, query string) bool {
+	escaped := false
+	for _, r := range query {
+		if escaped {
+			escaped = false
+			continue
+		}
+		if mode == ModeRegex && r == '\\' {
+			escaped = true
+			continue
+		}
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
 }
 
 func ResetDatabase() {
 	database = map[string]Project{}
+	touched = map[string]int{}
+	reset = true
 	isModified = true
 }
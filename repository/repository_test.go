@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestAddProject(t *testing.T) {
@@ -34,6 +35,7 @@ func TestUpdateCounter(t *testing.T) {
 	project.UpdateCounter()
 
 	assert.Equal(t, 1, project.CallCounter, "Expected call counter to be 1")
+	assert.WithinDuration(t, time.Now(), project.LastVisit, time.Minute, "Expected last visit to be now")
 	assert.Equal(t, project.CallCounter, database[path].CallCounter, "Expected call counter to be 1")
 	assert.True(t, isModified, "Expected isModified to be true")
 }
@@ -64,11 +66,21 @@ func TestSaveString(t *testing.T) {
 	assert.Equal(t, "/test/path/to/project;0", project.saveString(), "Expected save string to be '/test/path/to/project;0'")
 }
 
+func TestSaveStringWithLastVisit(t *testing.T) {
+	project := Project{
+		Path:        "/test/path/to/project",
+		CallCounter: 3,
+		LastVisit:   time.Unix(1700000000, 0),
+	}
+
+	assert.Equal(t, "/test/path/to/project;3;1700000000", project.saveString(), "Expected save string to contain the last visit")
+}
+
 func TestAddProjectFromDb(t *testing.T) {
 	initRepositoryTest(t)
 	path := "/test/path/to/project"
 
-	addProjectFromDb(path, 42)
+	addProjectFromDb(path, 42, time.Time{})
 
 	assert.Equal(t, 42, database[path].CallCounter, "Expected call counter to be 42")
 	assert.False(t, isModified, "Expected isModified to be false")
@@ -174,6 +186,18 @@ func TestReadDatabase(t *testing.T) {
 
 }
 
+func TestReadDatabaseLastVisit(t *testing.T) {
+	initRepositoryTest(t)
+
+	_ = os.WriteFile(config.Get().DatabaseFilePath, []byte("/test/path/to/project;42;1700000000\n/test/path/to/old/project;23\n"), 0644)
+
+	readDatabase()
+
+	assert.Equal(t, time.Unix(1700000000, 0), database["/test/path/to/project"].LastVisit, "Expected last visit to be read")
+	assert.True(t, database["/test/path/to/old/project"].LastVisit.IsZero(), "Expected no last visit for old entries")
+	assert.Equal(t, 23, database["/test/path/to/old/project"].CallCounter, "Expected old entries to still be read")
+}
+
 func TestWriteChangesToDatabase(t *testing.T) {
 	initRepositoryTest(t)
 
@@ -205,7 +229,64 @@ func TestWriteChangesToDatabase(t *testing.T) {
 
 }
 
-func TestGiveTopTen(t *testing.T) {
+func TestWriteChangesToDatabaseMergesOtherRuns(t *testing.T) {
+	initRepositoryTest(t)
+	dbPath := config.Get().DatabaseFilePath
+	_ = os.WriteFile(dbPath, []byte("/work/api;5\n/work/web;1\n/work/old;2\n"), 0644)
+	readDatabase()
+
+	// Another shell counts visits and adds a project in the meantime
+	_ = os.WriteFile(dbPath, []byte("/work/api;7;1700000000\n/work/web;1\n/work/old;2\n/work/new;1\n"), 0644)
+
+	project := GetProject("/work/api")
+	project.UpdateCounter()
+	RemoveProject("/work/old")
+	WriteChangesToDatabase()
+
+	projects, err := readDatabaseFile(dbPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, projects["/work/api"].CallCounter, "Expected the visit to be added to the counter on disk")
+	assert.WithinDuration(t, time.Now(), projects["/work/api"].LastVisit, time.Minute)
+	assert.Equal(t, 1, projects["/work/new"].CallCounter, "Expected the project of the other run to be kept")
+	assert.NotContains(t, projects, "/work/old", "Expected the removed project to be gone")
+	assert.Len(t, projects, 3)
+
+	entries, _ := os.ReadDir(filepath.Dir(dbPath))
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".gitcd.db.", "Expected no temporary file to be left behind")
+	}
+}
+
+func TestWriteChangesToDatabaseAfterReset(t *testing.T) {
+	initRepositoryTest(t)
+	dbPath := config.Get().DatabaseFilePath
+	_ = os.WriteFile(dbPath, []byte("/work/api;5\n"), 0644)
+
+	ResetDatabase()
+	AddProject("/work/web")
+	WriteChangesToDatabase()
+
+	content, _ := os.ReadFile(dbPath)
+	assert.Equal(t, "/work/web;0\n", string(content))
+}
+
+func TestWriteChangesToDatabaseUnchanged(t *testing.T) {
+	initRepositoryTest(t)
+	dbPath := config.Get().DatabaseFilePath
+	_ = os.WriteFile(dbPath, []byte("/work/api;5\n"), 0644)
+	old := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(dbPath, old, old)
+	readDatabase()
+
+	AddProject("/work/api")
+	WriteChangesToDatabase()
+
+	info, err := os.Stat(dbPath)
+	assert.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(old), "Expected the database not to be written without changes")
+}
+
+func TestGiveTop(t *testing.T) {
 	initRepositoryTest(t)
 
 	project1 := Project{
@@ -237,7 +318,7 @@ func TestGiveTopTen(t *testing.T) {
 		project5.Path: project5,
 	}
 
-	projects := GiveTopTen()
+	projects := GiveTop(10)
 
 	assert.Len(t, projects, 5, "Expected to have 5 projects")
 	assert.Equal(t, project1.Path, projects[1], "Expected path to be '%s'", project1.Path)
@@ -248,6 +329,33 @@ func TestGiveTopTen(t *testing.T) {
 
 }
 
+func TestGiveTopLimited(t *testing.T) {
+	initRepositoryTest(t)
+
+	project1 := Project{
+		Path:        "/test/path/to/project",
+		CallCounter: 42,
+	}
+	project2 := Project{
+		Path:        "/test/path/to/another/project",
+		CallCounter: 13,
+	}
+	project3 := Project{
+		Path:        "/test/path/to/yet/another/project",
+		CallCounter: 3,
+	}
+
+	database = map[string]Project{
+		project1.Path: project1,
+		project2.Path: project2,
+		project3.Path: project3,
+	}
+
+	projects := GiveTop(2)
+
+	assert.Equal(t, []string{project1.Path, project2.Path}, projects, "Expected the 2 most used projects")
+}
+
 func TestResetDatabase(t *testing.T) {
 	initRepositoryTest(t)
 
@@ -309,5 +417,149 @@ func initRepositoryTest(t *testing.T) {
 	_ = config.Init(c)
 	Init(c)
 	database = make(map[string]Project)
+	touched = make(map[string]int)
+	reset = false
 	isModified = false
 }
+
+func TestGetProjectsMatchingFuzzy(t *testing.T) {
+	initRepositoryTest(t)
+	path := "/test/path/to/project"
+	path2 := "/test/path/to/another/project"
+
+	AddProject(path)
+	AddProject(path2)
+
+	projects, _ := GetProjectsMatching(ModeFuzzy, Query{Include: []string{"anthrproj"}})
+
+	assert.Len(t, projects, 1, "Expected to have 1 project")
+	assert.Equal(t, path2, projects[0], "Expected path to be '%s'", path2)
+}
+
+func TestGetProjectsMatchingRanksByUsage(t *testing.T) {
+	initRepositoryTest(t)
+	path := "/test/path/to/project-a"
+	path2 := "/test/path/to/project-b"
+
+	database = map[string]Project{
+		path:  {Path: path, CallCounter: 0},
+		path2: {Path: path2, CallCounter: 20},
+	}
+
+	projects, _ := GetProjectsMatching(ModeFuzzy, Query{Include: []string{"project"}})
+
+	assert.Len(t, projects, 2, "Expected to have 2 projects")
+	assert.Equal(t, path2, projects[0], "Expected most used project to be ranked first")
+}
+
+func TestSmartCase(t *testing.T) {
+	initRepositoryTest(t)
+	cfg.SmartCase = true
+	path := "/test/path/to/MyProject"
+
+	AddProject(path)
+
+	for _, mode := range MatchModes {
+		projects, err := GetProjectsMatching(mode, Query{Include: []string{"myproject"}})
+		assert.NoError(t, err)
+		assert.Len(t, projects, 1, "Expected lowercase %s query to be case-insensitive", mode)
+
+		projects, err = GetProjectsMatching(mode, Query{Include: []string{"MyProject"}})
+		assert.NoError(t, err)
+		assert.Len(t, projects, 1, "Expected %s query with uppercase to match exact case", mode)
+
+		projects, err = GetProjectsMatching(mode, Query{Include: []string{"MYPROJECT"}})
+		assert.NoError(t, err)
+		assert.Empty(t, projects, "Expected %s query with uppercase to be case-sensitive", mode)
+	}
+}
+
+func TestSmartCaseOverridesCaseSensitive(t *testing.T) {
+	initRepositoryTest(t)
+	cfg.SmartCase = true
+	cfg.CaseSensitive = true
+	path := "/test/path/to/MyProject"
+
+	AddProject(path)
+
+	projects, _ := GetProjectsMatching(ModeSubstring, Query{Include: []string{"myproject"}})
+
+	assert.Len(t, projects, 1, "Expected lowercase query to be case-insensitive")
+}
+
+func TestHasUppercase(t *testing.T) {
+	assert.True(t, hasUppercase(ModeFuzzy, "myProject"))
+	assert.False(t, hasUppercase(ModeFuzzy, "myproject"))
+	assert.True(t, hasUppercase(ModeFuzzy, `\S`), "Expected escapes to count outside of regex mode")
+	assert.False(t, hasUppercase(ModeRegex, `my\S+project\W`), "Expected regex escapes to be ignored")
+	assert.True(t, hasUppercase(ModeRegex, `my\S+Project`))
+}
+
+func TestMatchRelativeToProjectRoot(t *testing.T) {
+	initRepositoryTest(t)
+	cfg.ProjectRootPath = "/home/user"
+	path := "/home/user/projects/api"
+
+	AddProject(path)
+
+	projects, _ := GetProjectsMatching(ModeSubstring, Query{Include: []string{"user"}})
+	assert.Empty(t, projects, "Expected the project root not to be matched")
+
+	projects, _ = GetProjectsMatching(ModeSubstring, Query{Include: []string{"projects/api"}})
+	assert.Len(t, projects, 1, "Expected the relative path to be matched")
+}
+
+func TestMatchFullPath(t *testing.T) {
+	initRepositoryTest(t)
+	cfg.ProjectRootPath = "/home/user"
+	SetFullPath(true)
+	path := "/home/user/projects/api"
+
+	AddProject(path)
+
+	projects, _ := GetProjectsMatching(ModeSubstring, Query{Include: []string{"user"}})
+
+	assert.Len(t, projects, 1, "Expected the full path to be matched")
+}
+
+func TestMatchText(t *testing.T) {
+	initRepositoryTest(t)
+	cfg.ProjectRootPath = "/home/user"
+
+	assert.Equal(t, "projects/api", matchText("/home/user/projects/api"))
+	assert.Equal(t, "user", matchText("/home/user"), "Expected the root itself to match on its name")
+	assert.Equal(t, "/opt/api", matchText("/opt/api"), "Expected paths outside the root to stay absolute")
+	assert.Equal(t, "/home/username/api", matchText("/home/username/api"))
+	assert.Equal(t, "/home", matchText("/home"))
+	assert.Equal(t, "..work/api", matchText("/home/user/..work/api"), "Expected directories starting with .. to be inside the root")
+}
+
+func TestBasenameRankedFirst(t *testing.T) {
+	for _, mode := range []MatchMode{ModeFuzzy, ModeRegex, ModeSubstring} {
+		initRepositoryTest(t)
+		path := "/test/api/service"
+		path2 := "/test/service/api"
+
+		database = map[string]Project{
+			path:  {Path: path, CallCounter: 5},
+			path2: {Path: path2, CallCounter: 0},
+		}
+
+		projects, _ := GetProjectsMatching(mode, Query{Include: []string{"api"}})
+
+		assert.Equal(t, []string{path2, path}, projects, "Expected %s basename hit to be ranked first", mode)
+	}
+}
+
+func TestSearchProjectsPositions(t *testing.T) {
+	initRepositoryTest(t)
+	cfg.ProjectRootPath = "/home/user"
+	path := "/home/user/projects/api"
+
+	AddProject(path)
+
+	matches, _ := SearchProjects(ModeSubstring, Query{Include: []string{"api"}})
+
+	assert.Len(t, matches, 1, "Expected to have 1 match")
+	assert.Equal(t, []int{20, 21, 22}, matches[0].Positions, "Expected positions to be relative to the full path")
+}
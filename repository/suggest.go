@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the maximum number of projects SuggestProjects returns.
+const maxSuggestions = 5
+
+// SuggestProjects returns the projects whose basename is closest to the
+// included terms of query, measured by edit distance. Projects that are too
+// far off to be a likely typo are left out, and so are the projects that the
+// excluded terms of query rule out in the given mode.
+func SuggestProjects(mode MatchMode, query Query) []string {
+	if len(query.Include) == 0 {
+		return []string{}
+	}
+	allowed, err := NewQueryMatcher(mode, Query{Exclude: query.Exclude})
+	if err != nil {
+		return []string{}
+	}
+
+	type suggestion struct {
+		Project
+		distance int
+	}
+	suggestions := make([]suggestion, 0)
+
+	for key, project := range database {
+		if _, _, matched := allowed.Match(matchText(key)); !matched {
+			continue
+		}
+		basename := strings.ToLower(filepath.Base(key))
+		total := 0
+		similar := true
+		for _, term := range query.Include {
+			term = strings.ToLower(term)
+			distance := editDistance(term, basename)
+			// A term can also be a typo of just a part of the basename
+			for _, part := range strings.FieldsFunc(basename, isSeparator) {
+				distance = min(distance, editDistance(term, part))
+			}
+			if distance > maxTypos(term) {
+				similar = false
+				break
+			}
+			total += distance
+		}
+		if similar {
+			suggestions = append(suggestions, suggestion{project, total})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+		if suggestions[i].CallCounter != suggestions[j].CallCounter {
+			return suggestions[i].CallCounter > suggestions[j].CallCounter
+		}
+		// Distance and CallCounters are equal, sort by Path, alphabetically, hence the "<"
+		return suggestions[i].Path < suggestions[j].Path
+	})
+
+	result := make([]string, 0)
+	for i := 0; i < len(suggestions) && i < maxSuggestions; i++ {
+		result = append(result, suggestions[i].Path)
+	}
+	return result
+}
+
+// maxTypos returns how many edits a term may be off to still be considered a
+// typo, which is about one in every three characters.
+func maxTypos(term string) int {
+	return max(1, len([]rune(term))/3)
+}
+
+func isSeparator(r rune) bool {
+	return r == '-' || r == '_' || r == '.' || r == ' '
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	previous := make([]int, len(t)+1)
+	current := make([]int, len(t)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		current[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(t)]
+}
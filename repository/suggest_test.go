@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("gitcd", "gitcd"))
+	assert.Equal(t, 1, editDistance("gitcd", "gitc"))
+	assert.Equal(t, 2, editDistance("gticd", "gitcd"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+	assert.Equal(t, 5, editDistance("", "gitcd"))
+}
+
+func TestSuggestProjects(t *testing.T) {
+	initRepositoryTest(t)
+	path := "/test/path/to/payments"
+	path2 := "/test/path/to/billing-service"
+	path3 := "/test/path/to/unrelated"
+
+	AddProject(path)
+	AddProject(path2)
+	AddProject(path3)
+
+	assert.Equal(t, []string{path}, SuggestProjects(ModeFuzzy, ParseQuery([]string{"paymnets"})))
+	assert.Equal(t, []string{path2}, SuggestProjects(ModeFuzzy, ParseQuery([]string{"biling"})), "Expected a typo of a part of the name to be suggested")
+	assert.Empty(t, SuggestProjects(ModeFuzzy, ParseQuery([]string{"kubernetes"})), "Expected no suggestions for unrelated queries")
+	assert.Empty(t, SuggestProjects(ModeFuzzy, ParseQuery([]string{"-payments"})), "Expected no suggestions without included terms")
+}
+
+func TestSuggestProjectsExcluded(t *testing.T) {
+	initRepositoryTest(t)
+	path := "/test/path/to/payments"
+	path2 := "/test/path/to/legacy/payments"
+
+	AddProject(path)
+	AddProject(path2)
+
+	assert.Equal(t, []string{path}, SuggestProjects(ModeFuzzy, ParseQuery([]string{"paymnets", "-legacy"})),
+		"Expected excluded projects not to be suggested")
+	assert.Empty(t, SuggestProjects(ModeRegex, ParseQuery([]string{"paymnets", "-("})),
+		"Expected no suggestions when the excluded terms are invalid")
+}
+
+func TestSuggestProjectsRanking(t *testing.T) {
+	initRepositoryTest(t)
+	path := "/test/path/to/api"
+	path2 := "/test/path/to/apo"
+	path3 := "/test/path/to/other/apo"
+
+	database = map[string]Project{
+		path:  {Path: path, CallCounter: 0},
+		path2: {Path: path2, CallCounter: 0},
+		path3: {Path: path3, CallCounter: 3},
+	}
+
+	suggestions := SuggestProjects(ModeFuzzy, ParseQuery([]string{"apx"}))
+
+	assert.Equal(t, []string{path3, path, path2}, suggestions, "Expected equal distances to be ranked by usage, then path")
+}